@@ -0,0 +1,109 @@
+package Test
+
+import (
+	"math/rand"
+	"rbtree/rbmap"
+	"runtime"
+	"testing"
+)
+
+// 统计还没有归还的节点个数
+type countingAllocator struct {
+	rbmap.Allocator
+	live int
+}
+
+func (a *countingAllocator) New() *rbmap.Node {
+	a.live++
+	return a.Allocator.New()
+}
+
+func (a *countingAllocator) Free(node *rbmap.Node) {
+	a.live--
+	a.Allocator.Free(node)
+}
+
+func TestAllocators(t *testing.T) {
+	for name, inner := range map[string]rbmap.Allocator{
+		"default": rbmap.DefaultAllocator{},
+		"pool":    rbmap.NewPoolAllocator(),
+		"arena":   rbmap.NewArenaAllocator(16),
+	} {
+		alloc := &countingAllocator{Allocator: inner}
+		mp := rbmap.NewMap(intCompare, rbmap.WithAllocator(alloc))
+		expected := map[int]int{}
+		r := rand.New(rand.NewSource(3))
+		for i := 0; i < 2000; i++ {
+			key := r.Intn(200)
+			if r.Intn(2) == 0 {
+				mp.Add(key, key)
+				expected[key] = key
+			} else {
+				mp.Delete(key)
+				delete(expected, key)
+			}
+		}
+		if err := mp.Validate(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		// n个键值对只使用n个节点，叶子共用哨兵节点，不从分配器分配
+		if mp.Len() != len(expected) || alloc.live != mp.Len() {
+			t.Fatalf("%s: expected %d live nodes, got %d", name, len(expected), alloc.live)
+		}
+		for key, val := range expected {
+			if ok, v := mp.Get(key); !ok || v != val {
+				t.Fatalf("%s: expected %d for %d, got %v", name, val, key, v)
+			}
+		}
+	}
+}
+
+func TestPool(t *testing.T) {
+	pool := rbmap.NewPool(intCompare, rbmap.WithAccessOrder())
+	for round := 0; round < 3; round++ {
+		mp := pool.Get()
+		if mp.Len() != 0 {
+			t.Fatalf("pooled map should be empty")
+		}
+		for i := 0; i < 100; i++ {
+			mp.Add(i, i)
+		}
+		mp.Pin(5)
+		if err := mp.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		if ok, key, _ := mp.LeastRecentlyUsed(); !ok || key != 0 {
+			t.Fatalf("expected 0 to be least recently used, got %v", key)
+		}
+		pool.Put(mp)
+		if mp.Len() != 0 || mp.PinnedLen() != 0 {
+			t.Fatalf("Put should clear the map")
+		}
+	}
+}
+
+// 测量每个键值对占用的堆内存（B/key），用于比较节点布局的改动，
+// 例如 go test ./Test -run '^$' -bench MapMemory
+func BenchmarkMapMemory(b *testing.B) {
+	const n = 100000
+	for name, opts := range map[string][]rbmap.Option{
+		"default":     nil,
+		"accessOrder": {rbmap.WithAccessOrder()},
+	} {
+		b.Run(name, func(b *testing.B) {
+			var before, after runtime.MemStats
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				mp := rbmap.NewMap(intCompare, opts...)
+				for key := 0; key < n; key++ {
+					mp.Add(key, nil)
+				}
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				b.ReportMetric(float64(int64(after.HeapAlloc)-int64(before.HeapAlloc))/n, "B/key")
+				runtime.KeepAlive(mp)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package Test
+
+import (
+	"rbtree/rbmap/btree"
+	"slices"
+	"testing"
+)
+
+// 收集遍历到的元素
+func collect(visit func(btree.ItemIterator)) []btree.Item {
+	var items []btree.Item
+	visit(func(i btree.Item) bool {
+		items = append(items, i)
+		return true
+	})
+	return items
+}
+
+func TestBTreeAdapter(t *testing.T) {
+	tr := btree.New(32)
+	for i := 9; i >= 0; i-- {
+		if old := tr.ReplaceOrInsert(btree.Int(i)); old != nil {
+			t.Fatalf("unexpected replaced item %v", old)
+		}
+	}
+	if old := tr.ReplaceOrInsert(btree.Int(5)); old != btree.Int(5) || tr.Len() != 10 {
+		t.Fatalf("expected to replace 5, got %v", old)
+	}
+	if tr.Min() != btree.Int(0) || tr.Max() != btree.Int(9) || !tr.Has(btree.Int(3)) {
+		t.Fatalf("unexpected min/max")
+	}
+	checks := []struct {
+		visit    func(btree.ItemIterator)
+		expected []btree.Item
+	}{
+		{func(it btree.ItemIterator) { tr.AscendRange(btree.Int(3), btree.Int(6), it) }, []btree.Item{btree.Int(3), btree.Int(4), btree.Int(5)}},
+		{func(it btree.ItemIterator) { tr.AscendLessThan(btree.Int(2), it) }, []btree.Item{btree.Int(0), btree.Int(1)}},
+		{func(it btree.ItemIterator) { tr.AscendGreaterOrEqual(btree.Int(8), it) }, []btree.Item{btree.Int(8), btree.Int(9)}},
+		{func(it btree.ItemIterator) { tr.DescendRange(btree.Int(6), btree.Int(3), it) }, []btree.Item{btree.Int(6), btree.Int(5), btree.Int(4)}},
+		{func(it btree.ItemIterator) { tr.DescendLessOrEqual(btree.Int(1), it) }, []btree.Item{btree.Int(1), btree.Int(0)}},
+		{func(it btree.ItemIterator) { tr.DescendGreaterThan(btree.Int(7), it) }, []btree.Item{btree.Int(9), btree.Int(8)}},
+	}
+	for i, check := range checks {
+		if got := collect(check.visit); !slices.Equal(got, check.expected) {
+			t.Fatalf("check %d: expected %v, got %v", i, check.expected, got)
+		}
+	}
+
+	clone := tr.Clone()
+	if tr.DeleteMin() != btree.Int(0) || tr.DeleteMax() != btree.Int(9) || tr.Delete(btree.Int(100)) != nil {
+		t.Fatalf("unexpected delete results")
+	}
+	if tr.Len() != 8 || clone.Len() != 10 {
+		t.Fatalf("clone should be independent")
+	}
+	tr.Clear(false)
+	if tr.Len() != 0 || tr.Min() != nil || tr.DeleteMin() != nil {
+		t.Fatalf("expected empty tree")
+	}
+}
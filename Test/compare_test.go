@@ -0,0 +1,106 @@
+package Test
+
+import (
+	"rbtree/rbmap"
+	"testing"
+)
+
+func TestLessToCompare(t *testing.T) {
+	mp := rbmap.NewMap(rbmap.LessToCompare(func(a, b string) bool { return a < b }))
+	for _, s := range []string{"c", "a", "b"} {
+		mp.Add(s, len(s))
+	}
+	// expected a b c
+	var keys []string
+	for pair := range mp.Range() {
+		keys = append(keys, pair.Key.(string))
+	}
+	if len(keys) != 3 || keys[0] != "a" || keys[1] != "b" || keys[2] != "c" {
+		t.Fatalf("unexpected order %v", keys)
+	}
+}
+
+func TestReverseAndDescending(t *testing.T) {
+	// 使用Reverse创建的map从大到小存放
+	mp := rbmap.NewMap(rbmap.Reverse(intCompare))
+	for i := 1; i <= 5; i++ {
+		mp.Add(i, i)
+	}
+	expected := 5
+	for pair := range mp.Range() {
+		if pair.Key.(int) != expected {
+			t.Fatalf("expected %d, got %v", expected, pair.Key)
+		}
+		expected--
+	}
+
+	// 逆序视图与原map共享数据
+	asc := newIntMap(5)
+	desc := asc.Descending()
+	desc.Add(6, 60)
+	if ok, val := asc.Get(6); !ok || val.(int) != 60 {
+		t.Fatalf("view should write through to the map")
+	}
+	expected = 6
+	for pair := range desc.Range() {
+		if pair.Key.(int) != expected {
+			t.Fatalf("expected %d, got %v", expected, pair.Key)
+		}
+		expected--
+	}
+	if expected != 0 {
+		t.Fatalf("descending view missed keys")
+	}
+}
+
+func TestNewOrderedMap(t *testing.T) {
+	mp := rbmap.NewOrderedMap[float64]()
+	for _, f := range []float64{2.5, -1, 3} {
+		mp.Add(f, nil)
+	}
+	var keys []float64
+	for key := range mp.RangeKeysOnly() {
+		keys = append(keys, key.(float64))
+	}
+	if len(keys) != 3 || keys[0] != -1 || keys[1] != 2.5 || keys[2] != 3 {
+		t.Fatalf("unexpected order %v", keys)
+	}
+}
+
+func TestInstrumentCompare(t *testing.T) {
+	ic := rbmap.InstrumentCompare(intCompare)
+	mp := rbmap.NewMap(ic.Compare)
+	for i := 0; i < 1000; i++ {
+		mp.Add(i, i)
+	}
+	ic.Reset()
+	mp.Get(500)
+	metrics := ic.Metrics()
+	if metrics.Calls == 0 || metrics.MaxPath != int(metrics.Calls) {
+		t.Fatalf("a single lookup should be one comparison path, got %+v", metrics)
+	}
+	hist, _ := mp.DepthHistogram()
+	if depth := len(hist); metrics.MaxPath > depth {
+		t.Fatalf("path %d is longer than the tree depth %d", metrics.MaxPath, depth)
+	}
+	mp.Get(1)
+	mp.Get(2)
+	if next := ic.Metrics(); next.Calls <= metrics.Calls || next.MaxPath > len(hist) {
+		t.Fatalf("unexpected metrics after more lookups %+v", next)
+	}
+
+	// 类型可以比较但是值不能用==比较的key（接口字段里存放切片）不能panic
+	type tagged struct{ parts interface{} }
+	ic = rbmap.InstrumentCompare(func(a, b interface{}) uint8 {
+		return intCompare(len(a.(tagged).parts.([]int)), len(b.(tagged).parts.([]int)))
+	})
+	tags := rbmap.NewMap(ic.Compare)
+	for i := 0; i < 10; i++ {
+		tags.Add(tagged{make([]int, i)}, i)
+	}
+	ic.Reset()
+	tags.Get(tagged{make([]int, 5)})
+	if metrics := ic.Metrics(); metrics.Calls == 0 || metrics.MaxPath != int(metrics.Calls) {
+		t.Fatalf("a single lookup should be one comparison path, got %+v", metrics)
+	}
+}
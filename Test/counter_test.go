@@ -0,0 +1,74 @@
+package Test
+
+import (
+	"rbtree/rbmap"
+	"testing"
+)
+
+func TestCounters(t *testing.T) {
+	mp := rbmap.NewOrderedMap[string]()
+	for _, word := range []string{"b", "a", "c", "a", "b", "a", "d", "b"} {
+		mp.Increment(word, 1)
+	}
+	if n, _ := mp.Increment("c", 2); n != 3 {
+		t.Fatalf("expected 3, got %d", n)
+	}
+	// a=3 b=3 c=3 d=1，计数相同时key小的在前
+	top := mp.ScanTopKByValue(2)
+	if len(top) != 2 || top[0].Key != "a" || top[1].Key != "b" || top[0].Val != int64(3) {
+		t.Fatalf("unexpected top k %v", top)
+	}
+	if len(mp.ScanTopKByValue(10)) != 4 {
+		t.Fatalf("expected all counters")
+	}
+
+	mp.Add("e", "not a counter")
+	_, gen := mp.Generation("e")
+	if _, err := mp.Increment("e", 1); err != rbmap.ErrNotCounter {
+		t.Fatalf("expected ErrNotCounter, got %v", err)
+	}
+	// 失败的Increment不能写入原来的值
+	if _, again := mp.Generation("e"); again != gen {
+		t.Fatalf("failed Increment should not rewrite the value")
+	}
+}
+
+func TestCounterIndex(t *testing.T) {
+	mp := rbmap.NewMap(intCompare, rbmap.WithCounterIndex(), rbmap.WithSoftDelete())
+	check := func(step string) {
+		t.Helper()
+		want, got := mp.ScanTopKByValue(5), mp.TopKByValue(5)
+		if len(want) != len(got) {
+			t.Fatalf("%s: expected %v, got %v", step, want, got)
+		}
+		for i := range want {
+			if want[i].Key != got[i].Key || want[i].Val != got[i].Val {
+				t.Fatalf("%s: expected %v, got %v", step, want, got)
+			}
+		}
+	}
+	for i := 0; i < 50; i++ {
+		mp.Increment(i%10, int64(i%7))
+	}
+	check("increment")
+	mp.Set(3, int64(100))
+	mp.Add(20, "not a counter")
+	check("set")
+	mp.Delete(3)
+	mp.DeleteRange(7, 8)
+	check("delete")
+	mp.Undelete(3)
+	mp.Compact()
+	check("undelete")
+	mp.WithValue(5, func(val *interface{}) { *val = int64(-1) })
+	mp.Update(6, func(val interface{}, exists bool) (interface{}, bool) { return int64(50), true })
+	check("update")
+	other := rbmap.NewMap(intCompare)
+	other.Add(1, int64(1))
+	mp.ReplaceAll(other)
+	check("replace")
+	mp.Clear()
+	if len(mp.TopKByValue(5)) != 0 {
+		t.Fatalf("expected empty index after Clear")
+	}
+}
@@ -0,0 +1,35 @@
+package Test
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDirtyTracking(t *testing.T) {
+	mp := newIntMap(10)
+	for _, k := range []int{3, 5, 7} {
+		mp.MarkDirty(k)
+	}
+	var dirty []int
+	for pair := range mp.RangeDirty() {
+		dirty = append(dirty, pair.Key.(int))
+	}
+	if len(dirty) != 3 || dirty[0] != 3 || dirty[2] != 7 {
+		t.Fatalf("unexpected dirty keys %v", dirty)
+	}
+
+	// 处理到7时出错，7保持为脏
+	errStop := errors.New("stop")
+	n, err := mp.FlushDirty(func(key, val interface{}) error {
+		if key.(int) == 7 {
+			return errStop
+		}
+		return nil
+	})
+	if n != 2 || err != errStop {
+		t.Fatalf("expected 2 flushed and errStop, got %d %v", n, err)
+	}
+	if mp.IsDirty(3) || !mp.IsDirty(7) {
+		t.Fatalf("unexpected dirty state after flush")
+	}
+}
@@ -0,0 +1,374 @@
+package Test
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"rbtree/rbmap"
+	"strings"
+	"testing"
+)
+
+func TestDumpLoadSharded(t *testing.T) {
+	mp := newIntMap(1000)
+	for i := 1; i <= 1000; i += 7 {
+		mp.Delete(i)
+	}
+	mp.SetMeta(2, 5)
+	dir := t.TempDir()
+	if err := mp.DumpSharded(dir, 8); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := rbmap.LoadSharded(dir, intCompare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Equal(mp) || loaded.Len() != mp.Len() {
+		t.Fatalf("loaded map differs from dumped map")
+	}
+	if _, meta := loaded.GetMeta(2); meta != 5 {
+		t.Fatalf("expected meta 5, got %d", meta)
+	}
+	checkExported(t, mustExport(t, loaded), false)
+
+	// 分片数多于键值对个数
+	small := newIntMap(3)
+	dir = t.TempDir()
+	if err := small.DumpSharded(dir, 5); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err = rbmap.LoadSharded(dir, intCompare); err != nil || !loaded.Equal(small) {
+		t.Fatalf("small map round trip failed: %v", err)
+	}
+}
+
+func TestDumpShardedFewerShards(t *testing.T) {
+	dir := t.TempDir()
+	if err := newIntMap(1000).DumpSharded(dir, 8); err != nil {
+		t.Fatal(err)
+	}
+	// 用更少的分片重新写入同一个目录，旧的分片文件不能被读入
+	mp := newIntMap(100)
+	if err := mp.DumpSharded(dir, 4); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "shard-*.gob")); len(files) != 4 {
+		t.Fatalf("expected 4 shard files, got %d", len(files))
+	}
+	loaded, err := rbmap.LoadSharded(dir, intCompare)
+	if err != nil || !loaded.Equal(mp) || loaded.Len() != 100 {
+		t.Fatalf("re-dumped map differs: %v", err)
+	}
+	if err := loaded.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDumpShardedKeepsSnapshotOnFailure(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "snapshot")
+	mp := newIntMap(1000)
+	if err := mp.DumpSharded(dir, 4); err != nil {
+		t.Fatal(err)
+	}
+	// 名字相似但不是分片文件的文件不能被删除，也不能被当作分片读入
+	for _, name := range []string{"shard-notes.gob", "README"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("keep"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := newIntMap(10).DumpShardedCtx(ctx, dir, 2); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	loaded, err := rbmap.LoadSharded(dir, intCompare)
+	if err != nil || !loaded.Equal(mp) {
+		t.Fatalf("failed dump should keep the previous snapshot: %v", err)
+	}
+
+	small := newIntMap(10)
+	if err := small.DumpSharded(dir, 2); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err = rbmap.LoadSharded(dir, intCompare); err != nil || !loaded.Equal(small) {
+		t.Fatalf("second dump should replace the snapshot: %v", err)
+	}
+	for _, name := range []string{"shard-notes.gob", "README"} {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(data) != "keep" {
+			t.Fatalf("unrelated file %s should survive: %v", name, err)
+		}
+	}
+	// 不留下临时目录
+	if entries, _ := os.ReadDir(parent); len(entries) != 1 {
+		t.Fatalf("expected only the snapshot directory, got %d entries", len(entries))
+	}
+}
+
+func TestLoadShardedCorruptChunk(t *testing.T) {
+	mp := newIntMap(3000)
+	dir := t.TempDir()
+	if err := mp.DumpSharded(dir, 1); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "shard-000000.gob")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 损坏第二块中间的一个字节
+	data[len(data)/2] ^= 0xff
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = rbmap.LoadSharded(dir, intCompare)
+	var corrupt *rbmap.ChecksumError
+	if !errors.As(err, &corrupt) {
+		t.Fatalf("expected ChecksumError, got %v", err)
+	}
+	if corrupt.Chunk != 1 || corrupt.Lo != 1025 || corrupt.Hi != 2048 || corrupt.After != 1024 {
+		t.Fatalf("unexpected corrupt range %+v", corrupt)
+	}
+	if err := rbmap.VerifySharded(dir); !errors.As(err, &corrupt) {
+		t.Fatalf("VerifySharded should report the corrupt chunk, got %v", err)
+	}
+}
+
+func TestDumpShardedCompressed(t *testing.T) {
+	opt := rbmap.WithSnapshotCompression(rbmap.FlateCompressor{})
+	plain, compressed := rbmap.NewMap(intCompare), rbmap.NewMap(intCompare, opt)
+	for i := 0; i < 2000; i++ {
+		val := strings.Repeat(`{"name":"value"}`, 20)
+		plain.Add(i, val)
+		compressed.Add(i, val)
+	}
+	plainDir, compressedDir := t.TempDir(), t.TempDir()
+	if err := plain.DumpSharded(plainDir, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := compressed.DumpSharded(compressedDir, 2); err != nil {
+		t.Fatal(err)
+	}
+	if dirSize(t, compressedDir)*4 > dirSize(t, plainDir) {
+		t.Fatalf("compressed snapshot should be much smaller")
+	}
+	loaded, err := rbmap.LoadSharded(compressedDir, intCompare, opt)
+	if err != nil || !loaded.Equal(plain) {
+		t.Fatalf("compressed round trip failed: %v", err)
+	}
+	if _, err := rbmap.LoadSharded(compressedDir, intCompare); !errors.Is(err, rbmap.ErrNoCompressor) {
+		t.Fatalf("expected ErrNoCompressor, got %v", err)
+	}
+	if err := rbmap.VerifySharded(compressedDir, opt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 获得目录中所有文件的总大小
+func dirSize(t *testing.T, dir string) int64 {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += info.Size()
+	}
+	return total
+}
+
+func TestDumpShardedEncrypted(t *testing.T) {
+	newAEAD := func(key byte) cipher.AEAD {
+		block, err := aes.NewCipher(bytes.Repeat([]byte{key}, 32))
+		if err != nil {
+			t.Fatal(err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return aead
+	}
+	opts := []rbmap.Option{
+		rbmap.WithSnapshotEncryption(newAEAD(1)),
+		rbmap.WithSnapshotCompression(rbmap.FlateCompressor{}),
+	}
+	mp := rbmap.NewMap(intCompare, opts...)
+	for i := 0; i < 3000; i++ {
+		mp.Add(i, fmt.Sprintf("secret-%d", i))
+	}
+	dir := t.TempDir()
+	if err := mp.DumpSharded(dir, 2); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "shard-000000.gob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("secret")) {
+		t.Fatalf("snapshot should not contain plaintext values")
+	}
+	loaded, err := rbmap.LoadSharded(dir, intCompare, opts...)
+	if err != nil || !loaded.Equal(mp) {
+		t.Fatalf("encrypted round trip failed: %v", err)
+	}
+	if _, err := rbmap.LoadSharded(dir, intCompare); !errors.Is(err, rbmap.ErrNoCipher) {
+		t.Fatalf("expected ErrNoCipher, got %v", err)
+	}
+	var corrupt *rbmap.ChecksumError
+	_, err = rbmap.LoadSharded(dir, intCompare, rbmap.WithSnapshotEncryption(newAEAD(2)))
+	if !errors.As(err, &corrupt) || corrupt.Chunk != 0 {
+		t.Fatalf("wrong key should fail authentication, got %v", err)
+	}
+}
+
+// 分片文件中的一块，字段与rbmap中的定义一致，用来在测试中篡改快照
+type shardChunk struct {
+	Lo, Hi     interface{}
+	Count      int
+	Compressed bool
+	Encrypted  bool
+	End        bool
+	Data       []byte
+	Sum        uint32
+}
+
+// 读取分片文件中的所有块
+func readChunks(t *testing.T, file string) []shardChunk {
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var chunks []shardChunk
+	dec := gob.NewDecoder(f)
+	for {
+		var chunk shardChunk
+		if err := dec.Decode(&chunk); err == io.EOF {
+			return chunks
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+// 把块重新写入分片文件
+func writeChunks(t *testing.T, file string, chunks []shardChunk) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	for _, chunk := range chunks {
+		if err := enc.Encode(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(file, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDumpShardedEncryptedTampering(t *testing.T) {
+	block, err := aes.NewCipher(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt := rbmap.WithSnapshotEncryption(aead)
+	plain := newIntMap(3000)
+	encrypted := rbmap.NewMap(intCompare, opt)
+	encrypted.AddPairs(plain.Pairs())
+	plainDir, dir := t.TempDir(), t.TempDir()
+	if err := plain.DumpSharded(plainDir, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := encrypted.DumpSharded(dir, 2); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "shard-000000.gob")
+	original := readChunks(t, file)
+	// 两块数据加上结束块
+	if len(original) != 3 || !original[2].End {
+		t.Fatalf("expected two chunks and an end marker, got %d", len(original))
+	}
+	expectCorrupt := func(name string, chunk int) {
+		t.Helper()
+		var corrupt *rbmap.ChecksumError
+		if _, err := rbmap.LoadSharded(dir, intCompare, opt); !errors.As(err, &corrupt) || corrupt.Chunk != chunk {
+			t.Fatalf("%s: expected ChecksumError at chunk %d, got %v", name, chunk, err)
+		}
+		if err := rbmap.VerifySharded(dir, opt); !errors.As(err, &corrupt) {
+			t.Fatalf("%s: VerifySharded should fail, got %v", name, err)
+		}
+	}
+
+	// 用明文和匹配的CRC32替换一块
+	tampered := append([]shardChunk(nil), original...)
+	tampered[1] = readChunks(t, filepath.Join(plainDir, "shard-000000.gob"))[1]
+	writeChunks(t, file, tampered)
+	expectCorrupt("plaintext chunk", 1)
+
+	// 调换两块的顺序
+	tampered = []shardChunk{original[1], original[0], original[2]}
+	writeChunks(t, file, tampered)
+	expectCorrupt("swapped chunks", 0)
+
+	// 截断文件
+	writeChunks(t, file, original[:2])
+	expectCorrupt("truncated shard", 2)
+
+	// 用另一个分片的块替换
+	writeChunks(t, file, readChunks(t, filepath.Join(dir, "shard-000001.gob")))
+	expectCorrupt("replayed shard", 0)
+
+	writeChunks(t, file, original)
+	if loaded, err := rbmap.LoadSharded(dir, intCompare, opt); err != nil || !loaded.Equal(plain) {
+		t.Fatalf("untampered snapshot should load: %v", err)
+	}
+}
+
+func TestMapValueScan(t *testing.T) {
+	mp := newIntMap(2500)
+	mp.SetMeta(3, 7)
+	value, err := mp.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob, ok := value.([]byte)
+	if !ok {
+		t.Fatalf("expected []byte, got %T", value)
+	}
+	scanned := rbmap.NewMap(intCompare, rbmap.WithAccessOrder())
+	scanned.Add(-1, 0)
+	if err := scanned.Scan(blob); err != nil {
+		t.Fatal(err)
+	}
+	if !scanned.Equal(mp) {
+		t.Fatalf("scanned map differs")
+	}
+	if _, meta := scanned.GetMeta(3); meta != 7 {
+		t.Fatalf("expected meta 7, got %d", meta)
+	}
+	if ok, key, _ := scanned.LeastRecentlyUsed(); !ok || key != 1 {
+		t.Fatalf("expected access order to be rebuilt, got %v", key)
+	}
+	if err := scanned.Scan(nil); err != nil || scanned.Len() != 0 {
+		t.Fatalf("NULL should clear the map, err %v", err)
+	}
+	if err := scanned.Scan(42); err == nil {
+		t.Fatalf("expected error for unsupported type")
+	}
+}
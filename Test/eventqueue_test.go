@@ -0,0 +1,37 @@
+package Test
+
+import (
+	"rbtree/rbmap"
+	"slices"
+	"testing"
+)
+
+func TestEventQueue(t *testing.T) {
+	q := rbmap.NewEventQueue(intCompare)
+	q.AddEvent(5, "end a")
+	q.AddEvent(1, "start a")
+	q.AddEvent(3, "start b")
+	q.AddEvent(5, "end b")
+	q.AddEvent(5, "start c")
+	if q.Len() != 5 || q.Positions() != 3 {
+		t.Fatalf("expected 5 events at 3 positions, got %d and %d", q.Len(), q.Positions())
+	}
+	if ok, pos := q.Peek(); !ok || pos != 1 {
+		t.Fatalf("expected next position 1, got %v", pos)
+	}
+	if ok, pos, event := q.PopNext(); !ok || pos != 1 || event != "start a" {
+		t.Fatalf("unexpected event %v at %v", event, pos)
+	}
+	if ok, pos, events := q.PopBatch(); !ok || pos != 3 || !slices.Equal(events, []interface{}{"start b"}) {
+		t.Fatalf("unexpected batch %v at %v", events, pos)
+	}
+	if ok, pos, event := q.PopNext(); !ok || pos != 5 || event != "end a" {
+		t.Fatalf("unexpected event %v at %v", event, pos)
+	}
+	if ok, pos, events := q.PopBatch(); !ok || pos != 5 || !slices.Equal(events, []interface{}{"end b", "start c"}) {
+		t.Fatalf("unexpected batch %v at %v", events, pos)
+	}
+	if ok, _, _ := q.PopNext(); ok || q.Len() != 0 {
+		t.Fatalf("expected empty queue")
+	}
+}
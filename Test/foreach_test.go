@@ -0,0 +1,48 @@
+package Test
+
+import "testing"
+
+func TestForEachCheckpoint(t *testing.T) {
+	mp := newIntMap(100)
+	// 每次最多扫描30个键，记录下中断的位置再继续
+	var visited []int
+	var checkpoint interface{}
+	scan := func(key, val interface{}) bool {
+		if len(visited)%30 == 29 && checkpoint != key {
+			checkpoint = key
+			return false
+		}
+		visited = append(visited, key.(int))
+		return true
+	}
+	mp.ForEach(scan)
+	for len(visited) < 100 {
+		mp.ForEachFrom(checkpoint, scan)
+	}
+	for i, key := range visited {
+		if key != i+1 {
+			t.Fatalf("expected key %d at %d, got %d", i+1, i, key)
+		}
+	}
+
+	// 从不存在的键开始
+	var keys []int
+	mp.Delete(50)
+	mp.ForEachFrom(50, func(key, val interface{}) bool {
+		keys = append(keys, key.(int))
+		return len(keys) < 2
+	})
+	if len(keys) != 2 || keys[0] != 51 || keys[1] != 52 {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+
+	// 逆序视图
+	keys = keys[:0]
+	mp.Descending().ForEachFrom(50, func(key, val interface{}) bool {
+		keys = append(keys, key.(int))
+		return len(keys) < 2
+	})
+	if len(keys) != 2 || keys[0] != 49 || keys[1] != 48 {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+}
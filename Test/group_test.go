@@ -0,0 +1,37 @@
+package Test
+
+import (
+	"rbtree/rbmap"
+	"testing"
+)
+
+func TestGroupBy(t *testing.T) {
+	mp := newIntMap(30)
+	// 按照key除以10分组，再按照奇偶分组
+	groups := mp.GroupBy(func(key, val interface{}) interface{} {
+		return key.(int) / 10
+	}, intCompare)
+	if groups.Len() != 4 {
+		t.Fatalf("expected 4 groups, got %d", groups.Len())
+	}
+	_, inner := groups.Get(1)
+	first := inner.(*rbmap.Map)
+	if first.Len() != 10 {
+		t.Fatalf("expected 10 keys in group 1, got %d", first.Len())
+	}
+	expected := 10
+	for pair := range first.Range() {
+		if pair.Key.(int) != expected {
+			t.Fatalf("expected %d, got %v", expected, pair.Key)
+		}
+		expected++
+	}
+
+	nested := first.GroupBy(func(key, val interface{}) interface{} {
+		return key.(int) % 2
+	}, intCompare)
+	_, odd := nested.Get(1)
+	if odd.(*rbmap.Map).Len() != 5 {
+		t.Fatalf("expected 5 odd keys")
+	}
+}
@@ -0,0 +1,23 @@
+package Test
+
+import "rbtree/rbmap"
+
+// 测试用的int比较方法
+func intCompare(a, b interface{}) uint8 {
+	c, d := a.(int), b.(int)
+	if c < d {
+		return uint8(1)
+	} else if c > d {
+		return uint8(2)
+	}
+	return uint8(0)
+}
+
+// 创建一个存放了 [1, n] 的map, val = key * 10
+func newIntMap(n int) *rbmap.Map {
+	mp := rbmap.NewMap(intCompare)
+	for i := 1; i <= n; i++ {
+		mp.Add(i, i*10)
+	}
+	return mp
+}
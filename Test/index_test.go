@@ -0,0 +1,71 @@
+package Test
+
+import (
+	"math/rand"
+	"rbtree/rbmap/index"
+	"slices"
+	"testing"
+)
+
+func TestInvertedIndex(t *testing.T) {
+	x := index.New(intCompare)
+	docs := map[string][]int{
+		"red":   {1, 3, 5, 7, 9},
+		"green": {2, 3, 5, 8},
+		"blue":  {3, 4, 5, 9},
+	}
+	for term, ids := range docs {
+		for _, id := range ids {
+			x.AddPosting(term, id)
+		}
+	}
+	if x.AddPosting("red", 1) || x.Terms() != 3 {
+		t.Fatalf("duplicate posting should not be added")
+	}
+	if got := x.Intersect("red", "green", "blue"); !slices.Equal(got, []interface{}{3, 5}) {
+		t.Fatalf("unexpected intersection %v", got)
+	}
+	if got := x.Intersect("red", "missing"); len(got) != 0 {
+		t.Fatalf("missing term should give an empty intersection, got %v", got)
+	}
+	if got := x.Union("green", "blue", "missing"); !slices.Equal(got, []interface{}{2, 3, 4, 5, 8, 9}) {
+		t.Fatalf("unexpected union %v", got)
+	}
+	if !x.RemovePosting("green", 3) || x.RemovePosting("green", 3) {
+		t.Fatalf("remove should succeed exactly once")
+	}
+	if got := x.Postings("green"); !slices.Equal(got, []interface{}{2, 5, 8}) {
+		t.Fatalf("unexpected postings %v", got)
+	}
+}
+
+func TestInvertedIndexRandom(t *testing.T) {
+	// 与用go map计算的结果比较
+	r := rand.New(rand.NewSource(11))
+	x := index.New(intCompare)
+	sets := make([]map[int]bool, 4)
+	terms := []string{"a", "b", "c", "d"}
+	for i := range sets {
+		sets[i] = map[int]bool{}
+		for j := 0; j < 2000; j++ {
+			doc := r.Intn(3000)
+			sets[i][doc] = true
+			x.AddPosting(terms[i], doc)
+		}
+	}
+	var and, or []interface{}
+	for doc := 0; doc < 3000; doc++ {
+		if sets[0][doc] && sets[1][doc] && sets[2][doc] {
+			and = append(and, doc)
+		}
+		if sets[1][doc] || sets[3][doc] {
+			or = append(or, doc)
+		}
+	}
+	if got := x.Intersect("a", "b", "c"); !slices.Equal(got, and) {
+		t.Fatalf("intersection differs: %d vs %d docs", len(got), len(and))
+	}
+	if got := x.Union("b", "d"); !slices.Equal(got, or) {
+		t.Fatalf("union differs: %d vs %d docs", len(got), len(or))
+	}
+}
@@ -0,0 +1,306 @@
+package Test
+
+import (
+	"errors"
+	"maps"
+	"math/rand"
+	"rbtree/rbmap"
+	"slices"
+	"testing"
+)
+
+func TestStdlibIterators(t *testing.T) {
+	mp := newIntMap(5)
+	keys := slices.Collect(mp.Keys())
+	if len(keys) != 5 || keys[0] != 1 || keys[4] != 5 {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+	goMap := map[any]any{}
+	maps.Insert(goMap, mp.All())
+	if len(goMap) != 5 || goMap[3] != 30 {
+		t.Fatalf("unexpected go map %v", goMap)
+	}
+
+	// 从go map创建有序Map
+	collected, err := rbmap.Collect(maps.All(goMap), intCompare)
+	if err != nil || !collected.Equal(mp) {
+		t.Fatalf("collected map differs, err %v", err)
+	}
+	for key, val := range collected.All() {
+		if key.(int) == 3 {
+			break
+		}
+		if val.(int) != key.(int)*10 {
+			t.Fatalf("unexpected pair %v %v", key, val)
+		}
+	}
+	if pairs := mp.AppendTo(nil); len(pairs) != 5 || pairs[2].Val != 30 {
+		t.Fatalf("unexpected pairs %v", pairs)
+	}
+	// 已经存在的key直接覆盖，其他错误停止插入并返回
+	if err := collected.Insert(maps.All(map[any]any{1: 100})); err != nil {
+		t.Fatalf("overwriting should not fail, got %v", err)
+	}
+	mixed := rbmap.NewMap(intCompare, rbmap.WithKeyTypeCheck())
+	mixed.Add(1, 10)
+	if err := mixed.Insert(maps.All(map[any]any{"2": 20})); !errors.Is(err, rbmap.ErrKeyType) {
+		t.Fatalf("expected ErrKeyType, got %v", err)
+	}
+	if _, err := rbmap.Collect(mp.All(), intCompare, rbmap.WithRateLimit(1, 3, false)); err != rbmap.ErrRateLimited {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+}
+
+func TestBatches(t *testing.T) {
+	var sizes []int
+	next := 1
+	for batch := range newIntMap(10).Batches(4) {
+		sizes = append(sizes, len(batch))
+		for _, pair := range batch {
+			if pair.Key != next {
+				t.Fatalf("expected key %d, got %v", next, pair.Key)
+			}
+			next++
+		}
+	}
+	if len(sizes) != 3 || sizes[0] != 4 || sizes[2] != 2 {
+		t.Fatalf("unexpected batch sizes %v", sizes)
+	}
+}
+
+func TestMergeIterators(t *testing.T) {
+	a, b, c := rbmap.NewMap(intCompare), rbmap.NewMap(intCompare), rbmap.NewMap(intCompare)
+	for i := 0; i < 30; i++ {
+		[]*rbmap.Map{a, b, c}[i%3].Add(i, i)
+	}
+	b.Add(0, "dup")
+	var keys []int
+	var vals []any
+	for key, val := range rbmap.MergeIterators(a, b, c, rbmap.NewMap(intCompare)) {
+		keys = append(keys, key.(int))
+		vals = append(vals, val)
+	}
+	if len(keys) != 31 || !slices.IsSorted(keys) || vals[0] != 0 || vals[1] != "dup" {
+		t.Fatalf("unexpected merge result %v %v", keys, vals[:2])
+	}
+}
+
+func TestRangeBetweenFiltered(t *testing.T) {
+	mp := newIntMap(100)
+	calls := 0
+	even := func(key, val interface{}) bool {
+		calls++
+		return key.(int)%2 == 0
+	}
+	var keys []interface{}
+	for key := range mp.RangeBetweenFiltered(10, 20, even) {
+		keys = append(keys, key)
+	}
+	if !slices.Equal(keys, []interface{}{10, 12, 14, 16, 18, 20}) || calls != 11 {
+		t.Fatalf("unexpected keys %v after %d predicate calls", keys, calls)
+	}
+	for range mp.RangeBetweenFiltered(50, 40, even) {
+		t.Fatalf("empty interval should yield nothing")
+	}
+}
+
+func TestSample(t *testing.T) {
+	mp := newIntMap(100)
+	var sampled []any
+	for key := range mp.SampleEvery(25) {
+		sampled = append(sampled, key)
+	}
+	if !slices.Equal(sampled, []any{1, 26, 51, 76}) {
+		t.Fatalf("unexpected SampleEvery %v", sampled)
+	}
+	sampled = sampled[:0]
+	for key := range mp.SampleCount(5) {
+		sampled = append(sampled, key)
+	}
+	if !slices.Equal(sampled, []any{1, 25, 50, 75, 100}) {
+		t.Fatalf("unexpected SampleCount %v", sampled)
+	}
+	sampled = sampled[:0]
+	for key := range newIntMap(3).SampleCount(5) {
+		sampled = append(sampled, key)
+	}
+	if !slices.Equal(sampled, []any{1, 2, 3}) {
+		t.Fatalf("small map should return every entry, got %v", sampled)
+	}
+	for range mp.SampleCount(1) {
+		sampled = append(sampled, 0)
+	}
+	if len(sampled) != 4 {
+		t.Fatalf("SampleCount(1) should return only the minimum")
+	}
+	// 按排名取样时跳过被软删除的键值对
+	soft := rbmap.NewMap(intCompare, rbmap.WithSoftDelete())
+	for i := 1; i <= 10; i++ {
+		soft.Add(i, i)
+	}
+	soft.Delete(1)
+	soft.Delete(5)
+	sampled = sampled[:0]
+	for key := range soft.SampleCount(3) {
+		sampled = append(sampled, key)
+	}
+	if !slices.Equal(sampled, []any{2, 6, 10}) {
+		t.Fatalf("unexpected SampleCount with tombstones %v", sampled)
+	}
+}
+
+func TestSplitPoints(t *testing.T) {
+	mp := newIntMap(10)
+	mp.Delete(4)
+	if points := mp.SplitPoints(3); !slices.Equal(points, []any{5, 8}) {
+		t.Fatalf("unexpected split points %v", points)
+	}
+	if points := mp.SplitPoints(1); points != nil {
+		t.Fatalf("one partition needs no split points, got %v", points)
+	}
+	if points := newIntMap(2).SplitPoints(5); !slices.Equal(points, []any{2}) {
+		t.Fatalf("small map should split into one entry per partition, got %v", points)
+	}
+}
+
+func TestIterator(t *testing.T) {
+	mp := newIntMap(10)
+	mp.Delete(5)
+	it := mp.Iterator()
+	if it.Valid() || it.Key() != nil {
+		t.Fatalf("new iterator should not be positioned on an entry")
+	}
+	var keys []any
+	for ok := it.Seek(4); ok; ok = it.Next() {
+		keys = append(keys, it.Key())
+	}
+	if !slices.Equal(keys, []any{4, 6, 7, 8, 9, 10}) {
+		t.Fatalf("unexpected forward scan %v", keys)
+	}
+	// 越过末尾后Prev回到最大的键值对
+	if !it.Prev() || it.Key() != 10 || it.Value() != 100 {
+		t.Fatalf("expected 10 after stepping back, got %v", it.Key())
+	}
+	keys = keys[:0]
+	for ok := it.Seek(5); ok; ok = it.Prev() {
+		keys = append(keys, it.Key())
+	}
+	if !slices.Equal(keys, []any{6, 4, 3, 2, 1}) {
+		t.Fatalf("unexpected backward scan %v", keys)
+	}
+	if !it.Next() || it.Key() != 1 {
+		t.Fatalf("expected 1 after stepping forward from the start, got %v", it.Key())
+	}
+	if it.Seek(11) || it.Valid() || !it.Last() || it.Key() != 10 || !it.First() || it.Key() != 1 {
+		t.Fatalf("unexpected Seek/First/Last behaviour")
+	}
+	if rbmap.NewMap(intCompare).Iterator().Next() {
+		t.Fatalf("empty map has no entries")
+	}
+}
+
+func TestIntersectExceptIter(t *testing.T) {
+	small, big := rbmap.NewMap(intCompare), rbmap.NewMap(intCompare)
+	for _, key := range []int{3, 500, 501, 9999, 20000} {
+		small.Add(key, -key)
+	}
+	for i := 0; i < 10000; i++ {
+		big.Add(i, i)
+	}
+	var keys, vals []any
+	for key, val := range rbmap.IntersectIter(small.Iterator(), big.Iterator()) {
+		keys, vals = append(keys, key), append(vals, val)
+	}
+	if !slices.Equal(keys, []any{3, 500, 501, 9999}) || !slices.Equal(vals, []any{-3, -500, -501, -9999}) {
+		t.Fatalf("unexpected intersection %v %v", keys, vals)
+	}
+	keys = keys[:0]
+	for key := range rbmap.ExceptIter(small.Iterator(), big.Iterator()) {
+		keys = append(keys, key)
+	}
+	if !slices.Equal(keys, []any{20000}) {
+		t.Fatalf("unexpected difference %v", keys)
+	}
+	// 从游标当前的位置开始
+	it := big.Iterator()
+	it.Seek(9990)
+	keys = keys[:0]
+	for key := range rbmap.ExceptIter(it, small.Iterator()) {
+		keys = append(keys, key)
+	}
+	if len(keys) != 9 || keys[0] != 9990 || keys[8] != 9998 {
+		t.Fatalf("unexpected difference from a positioned iterator %v", keys)
+	}
+}
+
+func TestIteratorGallopingSeek(t *testing.T) {
+	calls := 0
+	counting := func(a, b interface{}) uint8 {
+		calls++
+		return intCompare(a, b)
+	}
+	big := rbmap.NewMap(counting)
+	for i := 0; i < 100000; i++ {
+		big.Add(i*2, i)
+	}
+	r := rand.New(rand.NewSource(1))
+	// 依次Seek越来越大的key，结果与从头查找一致
+	it, key := big.Iterator(), 0
+	for key < 200000 {
+		key += r.Intn(50)
+		want := key + key%2
+		if ok := it.Seek(key); ok != (want < 200000) || ok && it.Key() != want {
+			t.Fatalf("Seek(%d) landed on %v", key, it.Key())
+		}
+	}
+	small := rbmap.NewMap(intCompare)
+	for _, key := range []int{10, 11, 50000, 50002, 199998} {
+		small.Add(key, nil)
+	}
+	calls = 0
+	count := 0
+	for range rbmap.IntersectIter(small.Iterator(), big.Iterator()) {
+		count++
+	}
+	if count != 4 {
+		t.Fatalf("expected 4 common keys, got %d", count)
+	}
+	if calls > 300 {
+		t.Fatalf("intersection with a small side should not scan the big side, %d comparisons", calls)
+	}
+}
+
+func TestRangeBetween(t *testing.T) {
+	mp := newIntMap(10)
+	mp.Delete(6)
+	collect := func(seq func(func(any, any) bool)) []any {
+		var keys []any
+		for key := range seq {
+			keys = append(keys, key)
+		}
+		return keys
+	}
+	if keys := collect(mp.RangeBetween(3, 7)); !slices.Equal(keys, []any{3, 4, 5, 7}) {
+		t.Fatalf("unexpected closed range %v", keys)
+	}
+	cases := []struct {
+		bounds rbmap.Bounds
+		want   []any
+	}{
+		{rbmap.Closed, []any{3, 4, 5, 7}},
+		{rbmap.ClosedOpen, []any{3, 4, 5}},
+		{rbmap.OpenClosed, []any{4, 5, 7}},
+		{rbmap.Open, []any{4, 5}},
+	}
+	for _, c := range cases {
+		if keys := collect(mp.RangeBetweenBounds(3, 7, c.bounds)); !slices.Equal(keys, c.want) {
+			t.Fatalf("bounds %d: expected %v, got %v", c.bounds, c.want, keys)
+		}
+	}
+	if keys := collect(mp.RangeBetweenBounds(5, 7, rbmap.Open)); len(keys) != 0 {
+		t.Fatalf("open range around a deleted key should be empty, got %v", keys)
+	}
+	if keys := collect(mp.RangeBetween(8, 100)); !slices.Equal(keys, []any{8, 9, 10}) {
+		t.Fatalf("unexpected tail range %v", keys)
+	}
+}
@@ -0,0 +1,59 @@
+package Test
+
+import (
+	"rbtree/rbmap/latency"
+	"testing"
+	"time"
+)
+
+func TestLatencyRecorder(t *testing.T) {
+	r := latency.New()
+	for i := 100; i >= 1; i-- {
+		r.Record(time.Duration(i) * time.Millisecond)
+	}
+	if r.P50() != 50*time.Millisecond || r.P95() != 95*time.Millisecond || r.P99() != 99*time.Millisecond {
+		t.Fatalf("unexpected quantiles %v %v %v", r.P50(), r.P95(), r.P99())
+	}
+	if r.Quantile(1) != 100*time.Millisecond || r.Quantile(0) != time.Millisecond {
+		t.Fatalf("unexpected extremes")
+	}
+	if qs := r.Quantiles(0.99, 0.5, 0.95); len(qs) != 3 || qs[0] != r.P99() || qs[1] != r.P50() || qs[2] != r.P95() {
+		t.Fatalf("Quantiles should match the single quantiles in argument order, got %v", qs)
+	}
+
+	bucketed := latency.New(latency.WithResolution(10 * time.Millisecond))
+	for i := 1; i <= 100; i++ {
+		bucketed.Record(time.Duration(i) * time.Millisecond)
+	}
+	if p := bucketed.P50(); p != 50*time.Millisecond {
+		t.Fatalf("expected bucketed median 50ms, got %v", p)
+	}
+
+	sampled := latency.New(latency.WithReservoir(200, 1))
+	for i := 0; i < 100000; i++ {
+		sampled.Record(time.Duration(i%1000) * time.Microsecond)
+	}
+	if sampled.Count() != 200 {
+		t.Fatalf("reservoir should hold 200 samples, got %d", sampled.Count())
+	}
+	if p := sampled.P50(); p < 400*time.Microsecond || p > 600*time.Microsecond {
+		t.Fatalf("sampled median too far off: %v", p)
+	}
+	// 默认限制样本个数，WithReservoir(0, seed)时保留所有样本
+	bounded, exact := latency.New(), latency.New(latency.WithReservoir(0, 1))
+	for i := 0; i < 2*latency.DefaultReservoirSize; i++ {
+		bounded.Record(time.Duration(i))
+		exact.Record(time.Duration(i))
+	}
+	if bounded.Count() != latency.DefaultReservoirSize || exact.Count() != 2*latency.DefaultReservoirSize {
+		t.Fatalf("unexpected sample counts %d %d", bounded.Count(), exact.Count())
+	}
+	if p := exact.P50(); p != time.Duration(latency.DefaultReservoirSize-1) {
+		t.Fatalf("expected exact median, got %v", p)
+	}
+
+	sampled.Reset()
+	if sampled.Count() != 0 || sampled.P99() != 0 || len(sampled.Quantiles(0.5, 0.9)) != 2 {
+		t.Fatalf("expected empty recorder")
+	}
+}
@@ -0,0 +1,95 @@
+package Test
+
+import (
+	"math/rand"
+	"rbtree/rbmap"
+	"testing"
+)
+
+func TestAccessOrder(t *testing.T) {
+	mp := rbmap.NewMap(intCompare, rbmap.WithAccessOrder())
+	for i := 1; i <= 5; i++ {
+		mp.Add(i, i)
+	}
+	mp.Get(1)
+	mp.Set(2, 2)
+	// 访问顺序从旧到新为 3 4 5 1 2
+	for _, expected := range []int{3, 4, 5, 1, 2} {
+		ok, key, _ := mp.EvictLeastRecentlyUsed()
+		if !ok || key != expected {
+			t.Fatalf("expected to evict %d, got %v", expected, key)
+		}
+	}
+	if ok, _, _ := mp.LeastRecentlyUsed(); ok || mp.Len() != 0 {
+		t.Fatalf("expected empty map")
+	}
+}
+
+func TestAccessOrderRandom(t *testing.T) {
+	// 与切片实现的访问顺序比较，删除有两个儿子的节点时访问顺序也要保持正确
+	r := rand.New(rand.NewSource(7))
+	mp := rbmap.NewMap(intCompare, rbmap.WithAccessOrder())
+	var order []int
+	touch := func(key int) {
+		for i, k := range order {
+			if k == key {
+				order = append(order[:i], order[i+1:]...)
+				break
+			}
+		}
+		order = append(order, key)
+	}
+	for i := 0; i < 5000; i++ {
+		key := r.Intn(100)
+		switch r.Intn(3) {
+		case 0:
+			mp.Add(key, key)
+			touch(key)
+		case 1:
+			if ok, _ := mp.Get(key); ok {
+				touch(key)
+			}
+		case 2:
+			if mp.Delete(key) == nil {
+				touch(key)
+				order = order[:len(order)-1]
+			}
+		}
+		if ok, lru, _ := mp.LeastRecentlyUsed(); ok != (len(order) > 0) || ok && lru != order[0] {
+			t.Fatalf("step %d: expected lru %v, got %v", i, order, lru)
+		}
+	}
+}
+
+func TestPin(t *testing.T) {
+	mp := rbmap.NewMap(intCompare, rbmap.WithAccessOrder())
+	for i := 1; i <= 7; i++ {
+		mp.Add(i, i)
+	}
+	mp.Pin(1)
+	mp.Pin(3)
+	if mp.Pin(100) || mp.PinnedLen() != 2 {
+		t.Fatalf("expected two pinned entries")
+	}
+	// 删除有两个儿子的节点会移动键值对，固定标记要跟着键值对移动
+	mp.Delete(4)
+	if !mp.IsPinned(3) || mp.IsPinned(2) {
+		t.Fatalf("pin should follow its entry")
+	}
+	for _, expected := range []int{2, 5, 6, 7} {
+		if ok, key, _ := mp.EvictLeastRecentlyUsed(); !ok || key != expected {
+			t.Fatalf("expected to evict %d, got %v", expected, key)
+		}
+	}
+	if ok, _, _ := mp.EvictLeastRecentlyUsed(); ok || mp.Len() != 2 {
+		t.Fatalf("pinned entries should never be evicted")
+	}
+	mp.Unpin(1)
+	mp.Delete(3)
+	if mp.PinnedLen() != 0 {
+		t.Fatalf("expected no pinned entries, got %d", mp.PinnedLen())
+	}
+	if ok, key, _ := mp.EvictLeastRecentlyUsed(); !ok || key != 1 {
+		t.Fatalf("unpinned entry should be evictable")
+	}
+}
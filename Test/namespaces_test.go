@@ -0,0 +1,74 @@
+package Test
+
+import (
+	"rbtree/rbmap"
+	"testing"
+)
+
+func TestNamespaces(t *testing.T) {
+	ns := rbmap.NewNamespaces(intCompare, rbmap.NamespaceQuota{
+		MaxEntries: 3,
+		MaxBytes:   10,
+		SizeOf:     func(key, val interface{}) int64 { return int64(len(val.(string))) },
+	})
+	if ns.Map("a") != nil {
+		t.Fatalf("tenant should be created lazily")
+	}
+	ns.Add("a", 1, "xx")
+	ns.Add("a", 2, "yy")
+	ns.Add("b", 1, "zzzz")
+	// 超过字节配额
+	if err := ns.Add("a", 3, "0123456789"); err != rbmap.ErrQuotaExceeded {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	ns.Add("a", 3, "w")
+	// 超过个数配额
+	if err := ns.Add("a", 4, "w"); err != rbmap.ErrQuotaExceeded {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	// 覆盖已有的值只计算字节差
+	if !ns.Set("a", 1, "xxxx") {
+		t.Fatalf("expected Set to succeed")
+	}
+	if entries, bytes := ns.Usage("a"); entries != 3 || bytes != 7 {
+		t.Fatalf("unexpected usage %d %d", entries, bytes)
+	}
+	ns.Delete("a", 2)
+	if stats := ns.Stats(); stats.Tenants != 2 || stats.Entries != 3 || stats.Bytes != 9 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	ns.Drop("b")
+	if tenants := ns.Tenants(); len(tenants) != 1 || tenants[0] != "a" || ns.Stats().Bytes != 5 {
+		t.Fatalf("unexpected tenants after drop %v", tenants)
+	}
+	if ok, val := ns.Map("a").Get(1); !ok || val != "xxxx" {
+		t.Fatalf("unexpected value %v", val)
+	}
+}
+
+func TestNamespacesRejectedOverwrite(t *testing.T) {
+	hashed := 0
+	hash := func(val interface{}) uint64 {
+		hashed++
+		return uint64(len(val.(string)))
+	}
+	ns := rbmap.NewNamespaces(intCompare, rbmap.NamespaceQuota{
+		MaxBytes: 4,
+		SizeOf:   func(key, val interface{}) int64 { return int64(len(val.(string))) },
+	}, rbmap.WithValueInterning(hash, nil))
+	ns.Add("a", 1, "xx")
+	before := hashed
+	// 覆盖后超过字节配额，原来的值不能被重新写入
+	if err := ns.Add("a", 1, "xxxxx"); err != rbmap.ErrQuotaExceeded {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	if hashed != before {
+		t.Fatalf("rejected write should not rewrite the stored value")
+	}
+	if ok, val := ns.Get("a", 1); !ok || val != "xx" {
+		t.Fatalf("unexpected value %v", val)
+	}
+	if _, bytes := ns.Usage("a"); bytes != 2 {
+		t.Fatalf("unexpected usage %d", bytes)
+	}
+}
@@ -0,0 +1,331 @@
+package Test
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"rbtree/rbmap"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNilKeyPolicy(t *testing.T) {
+	// nil键排在最前
+	mp := rbmap.NewMap(intCompare, rbmap.WithNilKeyPolicy(rbmap.NilKeyFirst))
+	mp.Add(2, 2)
+	mp.Add(nil, 0)
+	mp.Add(1, 1)
+	var keys []interface{}
+	for pair := range mp.Range() {
+		keys = append(keys, pair.Key)
+	}
+	if len(keys) != 3 || keys[0] != nil || keys[1] != 1 || keys[2] != 2 {
+		t.Fatalf("unexpected order %v", keys)
+	}
+
+	// nil键排在最后
+	mp = rbmap.NewMap(intCompare, rbmap.WithNilKeyPolicy(rbmap.NilKeyLast))
+	mp.Add(nil, 0)
+	mp.Add(1, 1)
+	keys = keys[:0]
+	for pair := range mp.Range() {
+		keys = append(keys, pair.Key)
+	}
+	if len(keys) != 2 || keys[0] != 1 || keys[1] != nil {
+		t.Fatalf("unexpected order %v", keys)
+	}
+
+	// 拒绝nil键
+	mp = rbmap.NewMap(intCompare, rbmap.WithNilKeyPolicy(rbmap.NilKeyReject))
+	if err := mp.Add(nil, 0); err != rbmap.ErrNilKey {
+		t.Fatalf("expected ErrNilKey, got %v", err)
+	}
+	if ok, _ := mp.Get(nil); ok || mp.Len() != 0 {
+		t.Fatalf("nil key should be rejected")
+	}
+}
+
+func TestValueEqual(t *testing.T) {
+	// 只比较绝对值的相等方法
+	abs := func(a, b interface{}) bool {
+		c, d := a.(int), b.(int)
+		return c == d || c == -d
+	}
+	a := rbmap.NewMap(intCompare, rbmap.WithValueEqual(abs))
+	b := rbmap.NewMap(intCompare)
+	for i := 1; i <= 10; i++ {
+		a.Add(i, i)
+		b.Add(i, -i)
+	}
+	if !a.Equal(b) {
+		t.Fatalf("expected maps to be equal under custom value equality")
+	}
+	if b.Equal(a) {
+		t.Fatalf("expected maps to differ under reflect.DeepEqual")
+	}
+
+	if !a.CompareAndSwap(3, -3, 30) {
+		t.Fatalf("expected CompareAndSwap to succeed")
+	}
+	if a.CompareAndSwap(3, 3, 31) {
+		t.Fatalf("expected CompareAndSwap to fail")
+	}
+	if _, val := a.Get(3); val.(int) != 30 {
+		t.Fatalf("expected 30, got %v", val)
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	mp := rbmap.NewMap(intCompare, rbmap.WithSoftDelete())
+	for i := 1; i <= 100; i++ {
+		mp.Add(i, i)
+	}
+	for i := 1; i <= 100; i += 2 {
+		mp.Delete(i)
+	}
+	if mp.Len() != 50 || mp.Tombstones() != 50 {
+		t.Fatalf("expected 50 live and 50 tombstones, got %d %d", mp.Len(), mp.Tombstones())
+	}
+	if ok, _ := mp.Get(1); ok {
+		t.Fatalf("soft deleted key should not be readable")
+	}
+	for pair := range mp.Range() {
+		if pair.Key.(int)%2 == 1 {
+			t.Fatalf("range returned soft deleted key %v", pair.Key)
+		}
+	}
+	if err := mp.Delete(1); err != rbmap.ErrNodeNotExists {
+		t.Fatalf("expected ErrNodeNotExists, got %v", err)
+	}
+
+	// 恢复和重新添加
+	if !mp.Undelete(1) || mp.Add(3, 33) != nil {
+		t.Fatalf("expected undelete and re-add to succeed")
+	}
+	if ok, val := mp.Get(1); !ok || val.(int) != 1 {
+		t.Fatalf("undelete should restore the old value")
+	}
+	if _, val := mp.Get(3); val.(int) != 33 {
+		t.Fatalf("re-add should store the new value")
+	}
+
+	if n := mp.Compact(); n != 48 || mp.Tombstones() != 0 || mp.Len() != 52 {
+		t.Fatalf("unexpected compaction result %d %d %d", n, mp.Tombstones(), mp.Len())
+	}
+	if mp.Undelete(5) {
+		t.Fatalf("compacted key cannot be undeleted")
+	}
+	if err := mp.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	mp := rbmap.NewMap(intCompare, rbmap.WithMaxDepth(2))
+	var err error
+	for i := 1; i <= 100 && err == nil; i++ {
+		err = mp.Add(i, i)
+	}
+	// 树高超过2以后查找会返回错误而不是继续递归
+	if err != rbmap.ErrTreeCorrupted {
+		t.Fatalf("expected ErrTreeCorrupted, got %v", err)
+	}
+
+	// 默认限制不会影响合法的树
+	mp = newIntMap(10000)
+	for i := 1; i <= 10000; i += 2 {
+		if err := mp.Delete(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestValueInterning(t *testing.T) {
+	hash := func(v interface{}) uint64 {
+		h := fnv.New64a()
+		h.Write([]byte(v.(string)))
+		return h.Sum64()
+	}
+	mp := rbmap.NewMap(intCompare, rbmap.WithValueInterning(hash, nil))
+	values := []string{"small", "medium", "large"}
+	for i := 0; i < 300; i++ {
+		// 每次都构造新的字符串，去重后只保存3份
+		mp.Add(i, strings.Repeat(values[i%3], 2))
+	}
+	if mp.InternedValues() != 3 {
+		t.Fatalf("expected 3 interned values, got %d", mp.InternedValues())
+	}
+	// 删除所有引用"large"的key后去重表中不再保存它
+	for i := 2; i < 300; i += 3 {
+		mp.Delete(i)
+	}
+	mp.Set(0, "other")
+	if mp.InternedValues() != 3 {
+		t.Fatalf("expected 3 interned values, got %d", mp.InternedValues())
+	}
+	if _, val := mp.Get(3); val.(string) != "smallsmall" {
+		t.Fatalf("unexpected value %v", val)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	mp := rbmap.NewMap(intCompare, rbmap.WithRateLimit(1, 2, false))
+	if mp.Add(1, 10) != nil || mp.Add(2, 20) != nil {
+		t.Fatalf("burst should allow two writes")
+	}
+	if err := mp.Add(3, 30); err != rbmap.ErrRateLimited {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if mp.Set(1, 11) || mp.Len() != 2 {
+		t.Fatalf("limited Set should fail without writing")
+	}
+	if ok, _ := mp.Get(1); !ok {
+		t.Fatalf("reads should not be limited")
+	}
+	if err := mp.Replace(1, 11); err != rbmap.ErrRateLimited {
+		t.Fatalf("Replace should report ErrRateLimited, got %v", err)
+	}
+	if err := mp.Replace(9, 90); err != rbmap.ErrNodeNotExists {
+		t.Fatalf("Replace should report a missing key, got %v", err)
+	}
+	if mp.CompareAndSwap(1, 10, 11) || mp.SetMeta(1, 1) || mp.MarkDirty(1) ||
+		mp.WithValue(1, func(val *interface{}) { *val = 11 }) {
+		t.Fatalf("every mutator should be limited")
+	}
+	if _, val := mp.Get(1); val != 10 {
+		t.Fatalf("limited writes should not change the value, got %v", val)
+	}
+
+	// 批量操作按照键值对个数消耗令牌
+	mp = rbmap.NewMap(intCompare, rbmap.WithRateLimit(1, 3, false))
+	mp.AddPairs(newIntMap(3).Pairs())
+	if mp.UpdateRange(1, 3, func(key, val interface{}) interface{} { return 0 }) != 0 || mp.DeleteRange(1, 3) != 0 || mp.Len() != 3 {
+		t.Fatalf("limited range operations should not write")
+	}
+	mp = rbmap.NewMap(intCompare, rbmap.WithRateLimit(10, 2, false))
+	mp.Add(1, 10)
+	mp.Add(2, 20)
+	time.Sleep(300 * time.Millisecond)
+	if mp.DeleteRange(1, 2) != 2 {
+		t.Fatalf("a full bucket should admit the range")
+	}
+	if err := mp.Add(3, 30); err != rbmap.ErrRateLimited {
+		t.Fatalf("DeleteRange should consume one token per key, got %v", err)
+	}
+	// 不等待时超过burst的批量操作不会成功
+	mp = rbmap.FromPairs(newIntMap(5).Pairs(), intCompare, rbmap.WithRateLimit(1000, 2, false))
+	if n := mp.DeleteRange(1, 5); n != 0 || mp.Len() != 5 {
+		t.Fatalf("a range larger than burst should be limited, removed %d", n)
+	}
+	if n := mp.DeleteRange(1, 2); n != 2 || mp.Len() != 3 {
+		t.Fatalf("a range within burst should be admitted, removed %d", n)
+	}
+	queue := rbmap.NewEventQueue(intCompare, rbmap.WithRateLimit(1, 1, false))
+	if err := queue.AddEvent(1, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _, _ := queue.PopNext(); ok || queue.Len() != 1 {
+		t.Fatalf("PopNext should be limited")
+	}
+	if ok, _, _ := queue.PopBatch(); ok || queue.Len() != 1 {
+		t.Fatalf("PopBatch should be limited")
+	}
+
+	mp = rbmap.NewMap(intCompare, rbmap.WithRateLimit(200, 1, true))
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := mp.Add(i, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Fatalf("blocking limiter should wait, took %v", elapsed)
+	}
+}
+
+func TestMonotonicKeys(t *testing.T) {
+	mp := rbmap.NewMap(intCompare, rbmap.WithMonotonicKeys())
+	for i := 1; i <= 10; i++ {
+		if err := mp.Add(i, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := mp.Add(5, 0)
+	if err != rbmap.ErrNodeAlreadyExists {
+		t.Fatalf("overwriting an existing key should be allowed, got %v", err)
+	}
+	mp.Delete(3)
+	err = mp.Add(3, 3)
+	var order *rbmap.OutOfOrderError
+	if !errors.Is(err, rbmap.ErrOutOfOrder) || !errors.As(err, &order) || order.Key != 3 || order.Max != 10 {
+		t.Fatalf("expected OutOfOrderError, got %v", err)
+	}
+	if err := mp.Update(0, func(val interface{}, exists bool) (interface{}, bool) { return 0, true }); !errors.Is(err, rbmap.ErrOutOfOrder) {
+		t.Fatalf("Update should reject out of order keys, got %v", err)
+	}
+	if err := mp.AppendMax(11, 11); err != nil || mp.Len() != 10 {
+		t.Fatalf("increasing key should be accepted, err %v", err)
+	}
+}
+
+func TestCooperativeYield(t *testing.T) {
+	mp := rbmap.NewMap(intCompare, rbmap.WithSoftDelete(), rbmap.WithYieldEvery(16))
+	for i := 0; i < 5000; i++ {
+		mp.Add(i, i)
+	}
+	for i := 0; i < 5000; i += 2 {
+		mp.Delete(i)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if n, err := mp.CompactCtx(ctx); n != 0 || !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled compaction should stop immediately, got %d %v", n, err)
+	}
+	if err := mp.DumpShardedCtx(ctx, t.TempDir(), 4); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if n, err := mp.DeleteRangeCtx(ctx, 0, 4999); n != 0 || !errors.Is(err, context.Canceled) || mp.Len() != 2500 {
+		t.Fatalf("cancelled DeleteRangeCtx should not delete, got %d %v", n, err)
+	}
+	// 不开启软删除时大区间走重建，取消后树保持不变
+	plain := newIntMap(1000)
+	if n, err := plain.DeleteRangeCtx(ctx, 10, 900); n != 0 || !errors.Is(err, context.Canceled) || plain.Len() != 1000 {
+		t.Fatalf("cancelled rebuild should leave the tree unchanged, got %d %v", n, err)
+	}
+	if err := plain.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := mp.CompactCtx(context.Background()); n != 2500 || err != nil || mp.Tombstones() != 0 {
+		t.Fatalf("unexpected compaction result %d %v", n, err)
+	}
+	if err := mp.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyTypeCheck(t *testing.T) {
+	mp := rbmap.NewMap(intCompare, rbmap.WithKeyTypeCheck())
+	// 还没有记录类型时读操作不受影响
+	if ok, _ := mp.Get(int64(1)); ok {
+		t.Fatalf("empty map has no keys")
+	}
+	if err := mp.Add(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	err := mp.Add(int64(2), 2)
+	var typeErr *rbmap.KeyTypeError
+	if !errors.Is(err, rbmap.ErrKeyType) || !errors.As(err, &typeErr) || typeErr.Got.Kind() != reflect.Int64 || typeErr.Want.Kind() != reflect.Int {
+		t.Fatalf("expected KeyTypeError, got %v", err)
+	}
+	if ok, _ := mp.Get(int64(1)); ok {
+		t.Fatalf("lookup with the wrong key type should miss")
+	}
+	if err := mp.Delete(int64(1)); !errors.Is(err, rbmap.ErrKeyType) {
+		t.Fatalf("expected ErrKeyType from Delete, got %v", err)
+	}
+	if ok, _ := mp.Get(1); !ok || mp.Len() != 1 {
+		t.Fatalf("map should be unchanged")
+	}
+}
@@ -0,0 +1,48 @@
+package Test
+
+import (
+	"math/rand"
+	"rbtree/rbmap"
+	"rbtree/rbmap/oracle"
+	"testing"
+)
+
+// 随机生成操作序列
+func randomOps(r *rand.Rand, n, keys int) []oracle.Op {
+	ops := make([]oracle.Op, n)
+	for i := range ops {
+		ops[i] = oracle.Op{Kind: oracle.OpKind(r.Intn(4)), Key: r.Intn(keys), Val: r.Int()}
+	}
+	return ops
+}
+
+func TestOracleCrossCheck(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ops := randomOps(r, 20000, 500)
+	if err := oracle.CrossCheck(rbmap.NewMap(intCompare), intCompare, ops); err != nil {
+		t.Fatal(err)
+	}
+	if err := oracle.CrossCheck(rbmap.NewMap(intCompare, rbmap.WithSoftDelete()), intCompare, ops); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 删除时不生效的错误实现
+type brokenMap struct {
+	*rbmap.Map
+}
+
+func (b brokenMap) Delete(key interface{}) error {
+	return nil
+}
+
+func TestOracleReportsDivergence(t *testing.T) {
+	ops := []oracle.Op{
+		{Kind: oracle.OpAdd, Key: 1, Val: 1},
+		{Kind: oracle.OpDelete, Key: 1},
+	}
+	err := oracle.CrossCheck(brokenMap{rbmap.NewMap(intCompare)}, intCompare, ops)
+	if d, ok := err.(*oracle.Divergence); !ok || d.Index != 1 {
+		t.Fatalf("expected divergence at op 1, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package Test
+
+import (
+	"rbtree/rbmap/orderbook"
+	"slices"
+	"testing"
+)
+
+func TestOrderBook(t *testing.T) {
+	book := orderbook.New()
+	book.Add("a1", orderbook.Ask, 101, 5)
+	book.Add("a2", orderbook.Ask, 100, 3)
+	book.Add("a3", orderbook.Ask, 100, 4)
+	book.Add("a4", orderbook.Ask, 103, 10)
+	book.Add("b1", orderbook.Bid, 99, 7)
+	book.Add("b2", orderbook.Bid, 98, 2)
+	if err := book.Add("b1", orderbook.Bid, 97, 1); err != orderbook.ErrDuplicateOrder {
+		t.Fatalf("expected ErrDuplicateOrder, got %v", err)
+	}
+	if ok, price, qty := book.BestAsk(); !ok || price != 100 || qty != 7 {
+		t.Fatalf("unexpected best ask %d x %d", price, qty)
+	}
+	if ok, price, qty := book.BestBid(); !ok || price != 99 || qty != 7 {
+		t.Fatalf("unexpected best bid %d x %d", price, qty)
+	}
+
+	// 价格优先、时间优先
+	fills, rest := book.Match(orderbook.Bid, 10)
+	expected := []orderbook.Fill{
+		{OrderID: "a2", Price: 100, Qty: 3},
+		{OrderID: "a3", Price: 100, Qty: 4},
+		{OrderID: "a1", Price: 101, Qty: 3},
+	}
+	if !slices.Equal(fills, expected) || rest != 0 {
+		t.Fatalf("unexpected fills %v rest %d", fills, rest)
+	}
+	if ok, price, qty := book.BestAsk(); !ok || price != 101 || qty != 2 || book.Levels(orderbook.Ask) != 2 {
+		t.Fatalf("unexpected best ask after match %d x %d", price, qty)
+	}
+
+	if !book.Cancel("b1") || book.Cancel("b1") {
+		t.Fatalf("cancel should succeed exactly once")
+	}
+	if ok, price, _ := book.BestBid(); !ok || price != 98 {
+		t.Fatalf("expected best bid 98, got %d", price)
+	}
+	fills, rest = book.Match(orderbook.Ask, 5)
+	if len(fills) != 1 || rest != 3 || book.Levels(orderbook.Bid) != 0 {
+		t.Fatalf("unexpected sell fills %v rest %d", fills, rest)
+	}
+	if ok, _, _ := book.BestBid(); ok || book.Len() != 2 {
+		t.Fatalf("expected no bids and 2 resting asks, got %d", book.Len())
+	}
+}
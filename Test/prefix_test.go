@@ -0,0 +1,80 @@
+package Test
+
+import (
+	"bytes"
+	"rbtree/rbmap"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrefixView(t *testing.T) {
+	mp := rbmap.NewMap(rbmap.LessToCompare(func(a, b string) bool { return a < b }))
+	users, orders := mp.WithPrefix("user/"), mp.WithPrefix("order/")
+	users.Add("alice", 1)
+	users.Add("bob", 2)
+	orders.Add("1", 100)
+	mp.Add("zzz", 0)
+
+	if ok, val := users.Get("bob"); !ok || val != 2 {
+		t.Fatalf("unexpected value %v", val)
+	}
+	if ok, _ := mp.Get("user/alice"); !ok {
+		t.Fatalf("view should write prefixed keys")
+	}
+	var keys []string
+	for pair := range users.Range() {
+		keys = append(keys, pair.Key.(string))
+	}
+	if strings.Join(keys, ",") != "alice,bob" || orders.Len() != 1 {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+
+	// []byte类型的key
+	bm := rbmap.NewMap(rbmap.LessToCompare(func(a, b []byte) bool { return bytes.Compare(a, b) < 0 }))
+	view := bm.WithPrefix([]byte("p:"))
+	view.Add([]byte("k"), 1)
+	view.ForEach(func(key, val interface{}) bool {
+		if string(key.([]byte)) != "k" {
+			t.Fatalf("unexpected key %s", key)
+		}
+		return true
+	})
+}
+
+func TestPaths(t *testing.T) {
+	mp := rbmap.NewOrderedMap[string]()
+	for _, key := range []string{"a", "a/b", "a/b/c", "a/b/d", "a/e/f", "a!", "ab", "b/x"} {
+		mp.Add(key, len(key))
+	}
+	paths := mp.Paths("/")
+
+	var keys []interface{}
+	for _, pair := range paths.Descendants("a") {
+		keys = append(keys, pair.Key)
+	}
+	if !reflect.DeepEqual(keys, []interface{}{"a/b", "a/b/c", "a/b/d", "a/e/f"}) {
+		t.Fatalf("unexpected descendants %v", keys)
+	}
+	if children := paths.ChildrenOf("a"); !reflect.DeepEqual(children, []string{"a/b", "a/e"}) {
+		t.Fatalf("unexpected children %v", children)
+	}
+	if children := paths.ChildrenOf(""); !reflect.DeepEqual(children, []string{"a", "a!", "ab", "b"}) {
+		t.Fatalf("unexpected root children %v", children)
+	}
+	if n, err := paths.DeleteSubtree("a/b"); n != 3 || err != nil || mp.Len() != 5 {
+		t.Fatalf("expected 3 deleted leaving 5, got %d and %d: %v", n, mp.Len(), err)
+	}
+	if ok, _ := mp.Get("a"); !ok {
+		t.Fatalf("parent should survive")
+	}
+
+	// 被限流时不删除任何键值对
+	limited := rbmap.FromPairs(mp.Pairs(), rbmap.OrderedCompare[string](), rbmap.WithRateLimit(1, 2, false))
+	if n, err := limited.Paths("/").DeleteSubtree("b"); n != 1 || err != nil {
+		t.Fatalf("expected 1 deleted, got %d: %v", n, err)
+	}
+	if n, err := limited.Paths("/").DeleteSubtree("a"); n != 0 || err != rbmap.ErrRateLimited || limited.Len() != 4 {
+		t.Fatalf("limited DeleteSubtree should delete nothing, got %d %v len %d", n, err, limited.Len())
+	}
+}
@@ -0,0 +1,95 @@
+package Test
+
+import (
+	"math/rand"
+	"rbtree/rbmap"
+	"sort"
+	"testing"
+)
+
+func TestRankSelect(t *testing.T) {
+	for _, opts := range [][]rbmap.Option{nil, {rbmap.WithSoftDelete()}} {
+		mp := rbmap.NewMap(intCompare, opts...)
+		present := map[int]bool{}
+		r := rand.New(rand.NewSource(7))
+		for step := 0; step < 20000; step++ {
+			key := r.Intn(2000)
+			switch {
+			case step%5000 == 4999:
+				mp.Compact()
+			case r.Intn(3) == 0:
+				mp.Delete(key)
+				delete(present, key)
+			default:
+				mp.Add(key, key)
+				present[key] = true
+			}
+		}
+		if err := mp.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		keys := make([]int, 0, len(present))
+		for key := range present {
+			keys = append(keys, key)
+		}
+		sort.Ints(keys)
+		for i, key := range keys {
+			if ok, got, _ := mp.Select(i); !ok || got != key {
+				t.Fatalf("Select(%d) = %v, want %d", i, got, key)
+			}
+			if rank := mp.Rank(key); rank != i {
+				t.Fatalf("Rank(%d) = %d, want %d", key, rank, i)
+			}
+		}
+		// 不存在的key的排名为小于它的key的个数
+		for key := -1; key <= 2000; key++ {
+			if present[key] {
+				continue
+			}
+			if rank, want := mp.Rank(key), sort.SearchInts(keys, key); rank != want {
+				t.Fatalf("Rank(%d) = %d, want %d", key, rank, want)
+			}
+		}
+		if ok, _, _ := mp.Select(len(keys)); ok {
+			t.Fatalf("Select past the end should fail")
+		}
+		if ok, _, _ := mp.Select(-1); ok {
+			t.Fatalf("negative index should fail")
+		}
+	}
+}
+
+func TestGetDeleteByIndex(t *testing.T) {
+	mp := rbmap.NewMap(intCompare, rbmap.WithAccessOrder())
+	for i := 1; i <= 10; i++ {
+		mp.Add(i, i*10)
+	}
+	if ok, key, val := mp.GetByIndex(3); !ok || key != 4 || val != 40 {
+		t.Fatalf("expected 4, got %v", key)
+	}
+	if ok, key, _ := mp.LeastRecentlyUsed(); !ok || key != 1 {
+		t.Fatalf("expected 1 to be least recently used, got %v", key)
+	}
+	mp.GetByIndex(0)
+	if _, key, _ := mp.LeastRecentlyUsed(); key != 2 {
+		t.Fatalf("GetByIndex should update access order, got %v", key)
+	}
+	// 每次删除排名为2的键值对
+	for _, want := range []int{3, 4, 5} {
+		if ok, key, val := mp.DeleteByIndex(2); !ok || key != want || val != want*10 {
+			t.Fatalf("expected to delete %d, got %v", want, key)
+		}
+	}
+	if mp.Len() != 7 {
+		t.Fatalf("expected 7 entries, got %d", mp.Len())
+	}
+	if ok, _, _ := mp.DeleteByIndex(7); ok {
+		t.Fatalf("index past the end should fail")
+	}
+	if ok, _, _ := mp.GetByIndex(-1); ok {
+		t.Fatalf("negative index should fail")
+	}
+	if err := mp.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,8 +1,11 @@
 package Test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"rbtree/rbmap"
+	"strings"
 	"testing"
 )
 
@@ -42,3 +45,612 @@ func TestMapAddFunc(t *testing.T) {
 		fmt.Println(pair.Key, pair.Val)
 	}
 }
+
+func TestMapDeleteLen(t *testing.T) {
+	for _, opts := range [][]rbmap.Option{nil, {rbmap.WithSoftDelete()}} {
+		mp := rbmap.NewMap(intCompare, opts...)
+		for i := 1; i <= 10; i++ {
+			mp.Add(i, i)
+		}
+		for i := 1; i <= 10; i++ {
+			if err := mp.Delete(i); err != nil {
+				t.Fatal(err)
+			}
+			if mp.Len() != 10-i {
+				t.Fatalf("expected len %d after deleting %d, got %d", 10-i, i, mp.Len())
+			}
+		}
+		// 删除不存在的key不改变长度
+		if err := mp.Delete(1); err != rbmap.ErrNodeNotExists || mp.Len() != 0 {
+			t.Fatalf("deleting a missing key changed len to %d, err %v", mp.Len(), err)
+		}
+	}
+}
+
+func TestMapRangeBuffered(t *testing.T) {
+	mp := newIntMap(100)
+	// 带缓冲通道遍历结果与Range一致
+	expected := 1
+	for pair := range mp.RangeBuffered(16) {
+		if pair.Key.(int) != expected || pair.Val.(int) != expected*10 {
+			t.Fatalf("expected %d, got %v", expected, pair)
+		}
+		expected++
+	}
+	if expected != 101 {
+		t.Fatalf("expected 100 pairs, got %d", expected-1)
+	}
+}
+
+func TestMapRangeCtx(t *testing.T) {
+	mp := newIntMap(100)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := mp.RangeCtx(ctx)
+	for i := 1; i <= 3; i++ {
+		if pair := <-ch; pair.Key.(int) != i {
+			t.Fatalf("expected %d, got %v", i, pair.Key)
+		}
+	}
+	cancel()
+	// 取消后通道很快关闭，最多还能读到一个键值对
+	rest := 0
+	for range ch {
+		rest++
+	}
+	if rest > 1 {
+		t.Fatalf("expected at most one pair after cancel, got %d", rest)
+	}
+	count := 0
+	for range mp.RangeCtx(context.Background()) {
+		count++
+	}
+	if count != 100 {
+		t.Fatalf("expected 100 pairs, got %d", count)
+	}
+}
+
+func TestMapMeta(t *testing.T) {
+	mp := newIntMap(20)
+	for i := 2; i <= 20; i += 2 {
+		mp.SetMeta(i, 1)
+	}
+	if mp.SetMeta(100, 1) {
+		t.Fatalf("SetMeta should fail on missing key")
+	}
+	// 删除节点后元数据需要跟随键值对移动
+	for i := 1; i <= 20; i += 3 {
+		mp.Delete(i)
+	}
+	for pair := range mp.Range() {
+		if expected := uint64(1 - pair.Key.(int)%2); pair.Meta != expected {
+			t.Fatalf("key %v: expected meta %d, got %d", pair.Key, expected, pair.Meta)
+		}
+	}
+	if ok, meta := mp.GetMeta(2); !ok || meta != 1 {
+		t.Fatalf("expected meta 1, got %d", meta)
+	}
+}
+
+func TestMapReadOnly(t *testing.T) {
+	mp := newIntMap(3)
+	ro := mp.ReadOnly()
+	mp.Add(4, 40)
+	// 只读视图能看到原map的修改
+	if ok, val := ro.Get(4); !ok || val.(int) != 40 || ro.Len() != 4 {
+		t.Fatalf("read only view should observe writes")
+	}
+}
+
+// 导出树结构，出错时直接失败
+func mustExport(t *testing.T, mp *rbmap.Map) *rbmap.TreeNode {
+	root, err := mp.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// 检查导出的树满足红黑树性质，返回黑高
+func checkExported(t *testing.T, node *rbmap.TreeNode, parentRed bool) int {
+	if node == nil {
+		return 1
+	}
+	if parentRed && node.Color == rbmap.RED {
+		t.Fatalf("red node %v has red parent", node.Key)
+	}
+	if node.Left != nil && node.Left.Key.(int) >= node.Key.(int) ||
+		node.Right != nil && node.Right.Key.(int) <= node.Key.(int) {
+		t.Fatalf("node %v violates ordering", node.Key)
+	}
+	l, r := checkExported(t, node.Left, node.Color), checkExported(t, node.Right, node.Color)
+	if l != r {
+		t.Fatalf("node %v has unequal black height", node.Key)
+	}
+	if node.Color == rbmap.BLACK {
+		l++
+	}
+	return l
+}
+
+func TestMapExport(t *testing.T) {
+	if root, err := rbmap.NewMap(intCompare).Export(); root != nil || err != nil {
+		t.Fatalf("empty map should export nil")
+	}
+	mp := newIntMap(100)
+	for i := 1; i <= 100; i += 3 {
+		mp.Delete(i)
+	}
+	root := mustExport(t, mp)
+	if root.Color != rbmap.BLACK {
+		t.Fatalf("root should be black")
+	}
+	checkExported(t, root, false)
+}
+
+func TestImportTree(t *testing.T) {
+	mp := newIntMap(50)
+	for i := 1; i <= 50; i += 4 {
+		mp.Delete(i)
+	}
+	// 合法的结构直接采用
+	imported, adopted, err := rbmap.ImportTree(mustExport(t, mp), intCompare)
+	if err != nil || !adopted || !imported.Equal(mp) || imported.Len() != 37 {
+		t.Fatalf("expected exported tree to be adopted, err %v", err)
+	}
+
+	// 颜色不合法的结构会被重建
+	root := &rbmap.TreeNode{Key: 2, Value: 20, Color: rbmap.RED,
+		Left:  &rbmap.TreeNode{Key: 1, Value: 10, Color: rbmap.RED},
+		Right: &rbmap.TreeNode{Key: 3, Value: 30, Color: rbmap.RED},
+	}
+	imported, adopted, err = rbmap.ImportTree(root, intCompare)
+	if err != nil || adopted || imported.Len() != 3 {
+		t.Fatalf("expected invalid tree to be rebuilt, err %v", err)
+	}
+	checkExported(t, mustExport(t, imported), false)
+
+	// 很深的链会被重建，不会栈溢出
+	var chain *rbmap.TreeNode
+	for i := 100000; i >= 1; i-- {
+		chain = &rbmap.TreeNode{Key: i, Value: i, Right: chain}
+	}
+	imported, adopted, err = rbmap.ImportTree(chain, intCompare)
+	if err != nil || adopted || imported.Len() != 100000 {
+		t.Fatalf("expected deep chain to be rebuilt, err %v", err)
+	}
+	// 有环的结构返回ErrTreeCorrupted
+	cycle := &rbmap.TreeNode{Key: 1, Value: 10, Right: &rbmap.TreeNode{Key: 2, Value: 20, Color: rbmap.RED}}
+	cycle.Right.Left = cycle
+	if _, _, err := rbmap.ImportTree(cycle, intCompare); !errors.Is(err, rbmap.ErrTreeCorrupted) {
+		t.Fatalf("expected ErrTreeCorrupted for cyclic tree, got %v", err)
+	}
+
+	// 重建时Add的错误会返回
+	mixed := &rbmap.TreeNode{Key: 1, Value: 10, Color: rbmap.RED, Right: &rbmap.TreeNode{Key: "2", Value: 20}}
+	if _, _, err := rbmap.ImportTree(mixed, intCompare, rbmap.WithKeyTypeCheck()); !errors.Is(err, rbmap.ErrKeyType) {
+		t.Fatalf("expected ErrKeyType, got %v", err)
+	}
+	if _, _, err := rbmap.ImportTree(chain, intCompare, rbmap.WithRateLimit(1, 10, false)); err != rbmap.ErrRateLimited {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+}
+
+func TestMapWriteMermaid(t *testing.T) {
+	var sb strings.Builder
+	if err := newIntMap(3).WriteMermaid(&sb); err != nil {
+		t.Fatal(err)
+	}
+	// 3个节点时根节点为2，两个儿子为红色
+	expected := "flowchart TD\n" +
+		"    classDef red fill:#d33,stroke:#900,color:#fff\n" +
+		"    classDef black fill:#222,stroke:#000,color:#fff\n" +
+		"    n0[\"2\"]:::black\n" +
+		"    n1[\"1\"]:::red\n" +
+		"    n0 -->|L| n1\n" +
+		"    n2[\"3\"]:::red\n" +
+		"    n0 -->|R| n2\n"
+	if sb.String() != expected {
+		t.Fatalf("unexpected mermaid output:\n%s", sb.String())
+	}
+}
+
+func TestMapRangeKeysValuesOnly(t *testing.T) {
+	mp := newIntMap(10)
+	expected := 1
+	for key := range mp.RangeKeysOnly() {
+		if key.(int) != expected {
+			t.Fatalf("expected key %d, got %v", expected, key)
+		}
+		expected++
+	}
+	expected = 1
+	for val := range mp.RangeValuesOnly() {
+		if val.(int) != expected*10 {
+			t.Fatalf("expected val %d, got %v", expected*10, val)
+		}
+		expected++
+	}
+}
+
+func TestMapPairs(t *testing.T) {
+	pairs := make([]rbmap.Pair, 0, 100)
+	for i := 1; i <= 100; i++ {
+		pairs = append(pairs, rbmap.Pair{Key: i, Val: i * 10})
+	}
+	// 有序的键值对直接构建
+	mp := rbmap.FromPairs(pairs, intCompare)
+	if err := mp.Validate(); err != nil || !mp.Equal(newIntMap(100)) {
+		t.Fatalf("bulk loaded map is invalid: %v", err)
+	}
+	if pairs[0].Compare(pairs[1], intCompare) != 1 {
+		t.Fatalf("expected pair 1 < pair 2")
+	}
+
+	// 合并键值对
+	if added := mp.AddPairs([]rbmap.Pair{{Key: 100, Val: 0}, {Key: 101, Val: 0}}); added != 1 {
+		t.Fatalf("expected 1 new key, got %d", added)
+	}
+	exported := mp.Pairs()
+	if len(exported) != 101 || exported[99].Val != 0 || exported[100].Key != 101 {
+		t.Fatalf("unexpected exported pairs")
+	}
+}
+
+func TestMapUpdate(t *testing.T) {
+	mp := newIntMap(3)
+	incr := func(val interface{}, exists bool) (interface{}, bool) {
+		if !exists {
+			return 1, true
+		}
+		return val.(int) + 1, true
+	}
+	mp.Update(1, incr)
+	mp.Update(4, incr)
+	if _, val := mp.Get(1); val.(int) != 11 {
+		t.Fatalf("expected 11, got %v", val)
+	}
+	if _, val := mp.Get(4); val.(int) != 1 || mp.Len() != 4 {
+		t.Fatalf("expected new key with value 1")
+	}
+	// 不保留时删除
+	mp.Update(2, func(val interface{}, exists bool) (interface{}, bool) { return nil, false })
+	if ok, _ := mp.Get(2); ok || mp.Len() != 3 {
+		t.Fatalf("expected key 2 to be deleted")
+	}
+}
+
+func TestMapWithValue(t *testing.T) {
+	mp := rbmap.NewMap(intCompare)
+	mp.Add(1, []int{1, 2})
+	ok := mp.WithValue(1, func(val *interface{}) {
+		*val = append((*val).([]int), 3)
+	})
+	if _, val := mp.Get(1); !ok || len(val.([]int)) != 3 {
+		t.Fatalf("expected value to be modified in place")
+	}
+	if mp.WithValue(2, func(val *interface{}) {}) {
+		t.Fatalf("expected missing key to return false")
+	}
+}
+
+func TestMapDeleteWhere(t *testing.T) {
+	mp := newIntMap(100)
+	even := func(key, val interface{}) bool { return key.(int)%2 == 0 }
+	total, rounds := 0, 0
+	n, resume, err := mp.DeleteWhere(even, 15)
+	for total += n; resume != nil && err == nil; total += n {
+		n, resume, err = mp.DeleteWhereFrom(resume, even, 15)
+		rounds++
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 50 || rounds != 3 || mp.Len() != 50 {
+		t.Fatalf("unexpected result total %d rounds %d len %d", total, rounds, mp.Len())
+	}
+	for key := range mp.RangeKeysOnly() {
+		if key.(int)%2 == 0 {
+			t.Fatalf("even key %v not deleted", key)
+		}
+	}
+
+	// 被限流时不删除，返回这一批的第一个key，之后可以从这里继续
+	mp = rbmap.FromPairs(newIntMap(10).Pairs(), intCompare, rbmap.WithRateLimit(1, 6, false))
+	if n, resume, err := mp.DeleteWhere(even, 0); n != 5 || resume != nil || err != nil {
+		t.Fatalf("unexpected result %d %v %v", n, resume, err)
+	}
+	if n, resume, err := mp.DeleteWhere(func(key, val interface{}) bool { return true }, 0); n != 0 || resume != 1 || err != rbmap.ErrRateLimited || mp.Len() != 5 {
+		t.Fatalf("limited DeleteWhere should delete nothing, got %d %v %v len %d", n, resume, err, mp.Len())
+	}
+}
+
+func TestAddIdempotent(t *testing.T) {
+	mp := rbmap.NewMap(intCompare, rbmap.WithIdempotencyWindow(2))
+	if err := mp.AddIdempotent("req-1", 1, 10); err != nil {
+		t.Fatal(err)
+	}
+	mp.Delete(1)
+	// 重试不会重新插入
+	if err := mp.AddIdempotent("req-1", 1, 10); err != nil || mp.Len() != 0 {
+		t.Fatalf("retry should be a no-op, err %v len %d", err, mp.Len())
+	}
+	mp.Add(2, 20)
+	if err := mp.AddIdempotent("req-2", 2, 21); err != rbmap.ErrNodeAlreadyExists {
+		t.Fatalf("expected ErrNodeAlreadyExists, got %v", err)
+	}
+	if err := mp.AddIdempotent("req-2", 3, 30); err != rbmap.ErrNodeAlreadyExists {
+		t.Fatalf("retry should return the first result, got %v", err)
+	}
+	// 窗口为2，req-1被淘汰后可以再次执行
+	mp.AddIdempotent("req-3", 4, 40)
+	if err := mp.AddIdempotent("req-1", 1, 10); err != nil || mp.Len() != 3 {
+		t.Fatalf("evicted request id should run again, err %v len %d", err, mp.Len())
+	}
+}
+
+func TestReplaceAll(t *testing.T) {
+	mp := rbmap.NewMap(intCompare, rbmap.WithAccessOrder())
+	mp.Add(100, 1000)
+	staging := newIntMap(10)
+	mp.ReplaceAll(staging)
+	if mp.Len() != 10 || staging.Len() != 0 {
+		t.Fatalf("expected contents to move, got %d and %d", mp.Len(), staging.Len())
+	}
+	if ok, _ := mp.Get(100); ok {
+		t.Fatalf("old contents should be gone")
+	}
+	if err := mp.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	// 访问顺序按照key的顺序建立，Get(100)没有命中不影响顺序
+	if ok, key, _ := mp.LeastRecentlyUsed(); !ok || key != 1 {
+		t.Fatalf("expected 1 to be least recently used, got %v", key)
+	}
+	staging.Add(1, 10)
+	if ok, val := mp.Get(1); !ok || val != 10 || mp.Len() != 10 {
+		t.Fatalf("staging map should be independent after the swap")
+	}
+}
+
+func TestUpdateRange(t *testing.T) {
+	mp := newIntMap(20)
+	n := mp.UpdateRange(5, 8, func(key, val interface{}) interface{} {
+		return val.(int) + 1
+	})
+	if n != 4 {
+		t.Fatalf("expected 4 updates, got %d", n)
+	}
+	for i := 1; i <= 20; i++ {
+		expected := i * 10
+		if i >= 5 && i <= 8 {
+			expected++
+		}
+		if _, val := mp.Get(i); val != expected {
+			t.Fatalf("expected %d for %d, got %v", expected, i, val)
+		}
+	}
+	if mp.UpdateRange(30, 40, func(key, val interface{}) interface{} { return 0 }) != 0 {
+		t.Fatalf("empty interval should update nothing")
+	}
+}
+
+func TestAppendMax(t *testing.T) {
+	mp := rbmap.NewMap(intCompare, rbmap.WithSoftDelete())
+	for i := 1; i <= 1000; i++ {
+		if err := mp.AppendMax(i, i); err != nil {
+			t.Fatal(err)
+		}
+		if i%10 == 0 {
+			// 删除最大的节点后缓存要失效
+			mp.Delete(i)
+		}
+		if i%100 == 0 {
+			mp.Compact()
+		}
+	}
+	// 不是递增的key退化为Add
+	if err := mp.AppendMax(10, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := mp.AppendMax(5, 0); err != rbmap.ErrNodeAlreadyExists {
+		t.Fatalf("expected ErrNodeAlreadyExists, got %v", err)
+	}
+	if err := mp.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if mp.Len() != 901 {
+		t.Fatalf("expected 901 entries, got %d", mp.Len())
+	}
+	var prev interface{}
+	for key := range mp.Keys() {
+		if prev != nil && key.(int) <= prev.(int) {
+			t.Fatalf("keys out of order: %v after %v", key, prev)
+		}
+		prev = key
+	}
+	mp.Add(2000, 0)
+	mp.AppendMax(2001, 0)
+	if ok, _ := mp.Get(2001); !ok || mp.Validate() != nil {
+		t.Fatalf("Add beyond the cached max should update the cache")
+	}
+}
+
+func TestGeneration(t *testing.T) {
+	mp := newIntMap(10)
+	ok, gen := mp.Generation(3)
+	if !ok || gen == 0 {
+		t.Fatalf("expected a generation for existing key")
+	}
+	if !mp.SetIfGeneration(3, gen, 33) {
+		t.Fatalf("unchanged generation should allow the write")
+	}
+	if mp.SetIfGeneration(3, gen, 34) {
+		t.Fatalf("stale generation should be rejected")
+	}
+	ok, val, newGen := mp.GetWithGeneration(3)
+	if !ok || val != 33 || newGen <= gen {
+		t.Fatalf("expected value 33 with a newer generation, got %v %d", val, newGen)
+	}
+	// 删除后重新添加的key不会复用以前的代数
+	mp.Delete(3)
+	mp.Add(3, 3)
+	if _, again := mp.Generation(3); again == newGen || mp.SetIfGeneration(3, newGen, 0) {
+		t.Fatalf("re-added key should get a fresh generation")
+	}
+	// 删除有两个儿子的节点时代数跟随键值对移动
+	_, gen = mp.Generation(5)
+	for i := 1; i <= 10; i += 2 {
+		if i != 5 {
+			mp.Delete(i)
+		}
+	}
+	if _, moved := mp.Generation(5); moved != gen {
+		t.Fatalf("generation should move with the entry")
+	}
+	if ok, _ := mp.Generation(100); ok || mp.SetIfGeneration(100, 0, 1) {
+		t.Fatalf("missing key has no generation")
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	mp := rbmap.NewMap(intCompare, rbmap.WithSoftDelete())
+	if ok, _, _ := mp.Min(); ok {
+		t.Fatalf("empty map has no minimum")
+	}
+	for i := 1; i <= 100; i++ {
+		mp.Add(i, i*10)
+	}
+	mp.Delete(1)
+	mp.Delete(100)
+	if ok, key, val := mp.Min(); !ok || key != 2 || val != 20 {
+		t.Fatalf("expected minimum 2, got %v", key)
+	}
+	if ok, key, val := mp.Max(); !ok || key != 99 || val != 990 {
+		t.Fatalf("expected maximum 99, got %v", key)
+	}
+}
+
+func TestNearestLookups(t *testing.T) {
+	mp := rbmap.NewMap(intCompare, rbmap.WithSoftDelete())
+	for i := 10; i <= 50; i += 10 {
+		mp.Add(i, i)
+	}
+	mp.Delete(30)
+	cases := []struct {
+		name string
+		fn   func(key interface{}) (bool, interface{}, interface{})
+		key  int
+		want interface{}
+	}{
+		{"Floor", mp.Floor, 20, 20},
+		{"Floor", mp.Floor, 35, 20},
+		{"Floor", mp.Floor, 5, nil},
+		{"Ceiling", mp.Ceiling, 20, 20},
+		{"Ceiling", mp.Ceiling, 25, 40},
+		{"Ceiling", mp.Ceiling, 55, nil},
+		{"Lower", mp.Lower, 20, 10},
+		{"Lower", mp.Lower, 40, 20},
+		{"Lower", mp.Lower, 10, nil},
+		{"Higher", mp.Higher, 20, 40},
+		{"Higher", mp.Higher, 45, 50},
+		{"Higher", mp.Higher, 50, nil},
+	}
+	for _, c := range cases {
+		ok, key, val := c.fn(c.key)
+		if ok != (c.want != nil) || key != c.want || val != c.want {
+			t.Fatalf("%s(%d) = %v %v, want %v", c.name, c.key, ok, key, c.want)
+		}
+	}
+}
+
+func TestCloneRange(t *testing.T) {
+	mp := newIntMap(100)
+	mp.SetMeta(30, 3)
+	clone := mp.CloneRange(25, 40, rbmap.WithAccessOrder())
+	if clone.Len() != 16 {
+		t.Fatalf("expected 16 entries, got %d", clone.Len())
+	}
+	if ok, key, _ := clone.Min(); !ok || key != 25 {
+		t.Fatalf("expected minimum 25, got %v", key)
+	}
+	if ok, key, _ := clone.Max(); !ok || key != 40 {
+		t.Fatalf("expected maximum 40, got %v", key)
+	}
+	if _, meta := clone.GetMeta(30); meta != 3 {
+		t.Fatalf("meta should be copied, got %d", meta)
+	}
+	if err := clone.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	// 修改副本不影响原Map
+	clone.Delete(30)
+	if ok, _ := mp.Get(30); !ok || mp.Len() != 100 {
+		t.Fatalf("original map should be unchanged")
+	}
+	if mp.CloneRange(200, 300).Len() != 0 {
+		t.Fatalf("empty range should produce an empty map")
+	}
+}
+
+func TestDeleteRange(t *testing.T) {
+	cases := []struct {
+		lo, hi, removed int
+	}{
+		{100, 109, 10},   // 逐个删除
+		{10, 900, 891},   // 重建
+		{-5, 2000, 1000}, // 全部删除
+		{500, 400, 0},    // 空区间
+	}
+	for _, c := range cases {
+		for _, opts := range [][]rbmap.Option{{rbmap.WithAccessOrder()}, {rbmap.WithSoftDelete()}} {
+			mp := rbmap.NewMap(intCompare, opts...)
+			for i := 1; i <= 1000; i++ {
+				mp.Add(i, i)
+			}
+			mp.Pin(5)
+			_, gen := mp.Generation(950)
+			if n := mp.DeleteRange(c.lo, c.hi); n != c.removed || mp.Len() != 1000-c.removed {
+				t.Fatalf("DeleteRange(%d, %d) removed %d, len %d", c.lo, c.hi, n, mp.Len())
+			}
+			if err := mp.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			for i := 1; i <= 1000; i++ {
+				inRange := i >= c.lo && i <= c.hi
+				if ok, _ := mp.Get(i); ok == inRange {
+					t.Fatalf("DeleteRange(%d, %d): key %d present %v", c.lo, c.hi, i, ok)
+				}
+			}
+			if c.removed < 1000 {
+				if ok, key, _ := mp.Select(0); !ok || mp.Rank(key) != 0 {
+					t.Fatalf("order statistics broken after DeleteRange")
+				}
+				if _, again := mp.Generation(950); again != gen {
+					t.Fatalf("surviving entries should keep their generation")
+				}
+				if c.hi < 5 || c.lo > 5 {
+					if !mp.IsPinned(5) || mp.PinnedLen() != 1 {
+						t.Fatalf("pin outside the range should survive")
+					}
+				} else if mp.PinnedLen() != 0 {
+					t.Fatalf("pin inside the range should be dropped")
+				}
+			}
+			if ok, key, _ := mp.LeastRecentlyUsed(); ok {
+				oldest := 1
+				if c.lo <= 1 && c.hi >= 1 {
+					oldest = c.hi + 1
+				}
+				if key != oldest {
+					t.Fatalf("expected %d to be least recently used, got %v", oldest, key)
+				}
+			}
+			// 删除后仍然可以正常增删
+			mp.Add(500, 500)
+			mp.Add(0, 0)
+			if err := mp.Validate(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
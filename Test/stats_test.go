@@ -0,0 +1,185 @@
+package Test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"rbtree/rbmap"
+	"reflect"
+	"testing"
+)
+
+func TestKeyDistribution(t *testing.T) {
+	mp := newIntMap(10)
+	buckets := mp.KeyDistribution(3)
+	// 10个key分成4, 3, 3
+	if len(buckets) != 3 || buckets[0].Lo != 1 || buckets[0].Hi != 4 || buckets[0].Count != 4 ||
+		buckets[2].Lo != 8 || buckets[2].Hi != 10 || buckets[2].Count != 3 {
+		t.Fatalf("unexpected buckets %v", buckets)
+	}
+	if len(mp.KeyDistribution(20)) != 10 {
+		t.Fatalf("buckets should be capped by the key count")
+	}
+}
+
+func TestDepthHistogram(t *testing.T) {
+	// 批量构建的树除了最后一层都是满的
+	pairs := newIntMap(10).Pairs()
+	hist, err := rbmap.FromPairs(pairs, intCompare).DepthHistogram()
+	if err != nil || len(hist) != 4 || hist[0] != 1 || hist[1] != 2 || hist[2] != 4 || hist[3] != 3 {
+		t.Fatalf("unexpected histogram %v", hist)
+	}
+	total := 0
+	hist, _ = newIntMap(1000).DepthHistogram()
+	for _, n := range hist {
+		total += n
+	}
+	if total != 1000 {
+		t.Fatalf("expected 1000 nodes, got %d", total)
+	}
+}
+
+func TestWalkDepthLimit(t *testing.T) {
+	// 批量构建不检查深度限制，得到一棵比限制更深的树，模拟树已经损坏
+	pairs := newIntMap(100).Pairs()
+	mp := rbmap.FromPairs(pairs, intCompare, rbmap.WithMaxDepth(3))
+	if _, err := mp.DepthHistogram(); err != rbmap.ErrTreeCorrupted {
+		t.Fatalf("expected ErrTreeCorrupted from DepthHistogram, got %v", err)
+	}
+	if _, err := mp.Export(); err != rbmap.ErrTreeCorrupted {
+		t.Fatalf("expected ErrTreeCorrupted from Export, got %v", err)
+	}
+	if err := mp.WriteMermaid(io.Discard); err != rbmap.ErrTreeCorrupted {
+		t.Fatalf("expected ErrTreeCorrupted from WriteMermaid, got %v", err)
+	}
+	if err := mp.Validate(); !errors.Is(err, rbmap.ErrTreeCorrupted) {
+		t.Fatalf("expected ErrTreeCorrupted from Validate, got %v", err)
+	}
+	if n, err := mp.DeleteRangeCtx(context.Background(), 10, 90); n != 0 || err != rbmap.ErrTreeCorrupted || mp.Len() != 100 {
+		t.Fatalf("DeleteRange should stop before changing a corrupted tree, got %d %v", n, err)
+	}
+	count := 0
+	for range mp.Range() {
+		count++
+	}
+	if count >= 100 {
+		t.Fatalf("Range should stop at the depth limit")
+	}
+	mp.Clear()
+	if mp.Len() != 0 {
+		t.Fatalf("Clear should reset a corrupted tree")
+	}
+}
+
+func TestKeyDigest(t *testing.T) {
+	a, b := newIntMap(100), newIntMap(100)
+	b.Delete(42)
+	b.Add(1000, 10000)
+
+	digestA := a.KeyDigest(4)
+	if len(digestA) != 4 || digestA[0].Lo != 1 || digestA[3].Hi != 100 || digestA[1].Count != 25 {
+		t.Fatalf("unexpected digest %v", digestA)
+	}
+	var differ []rbmap.DigestBucket
+	for _, bucket := range digestA {
+		other := b.RangeDigest(bucket.Lo, bucket.Hi, 1)
+		if len(other) != 1 || other[0].Hash != bucket.Hash {
+			differ = append(differ, bucket)
+		}
+	}
+	if len(differ) != 1 || differ[0].Lo != 26 {
+		t.Fatalf("expected only the bucket holding 42 to differ, got %v", differ)
+	}
+	// 细分不同的段直到找到缺失的key
+	lo, hi := differ[0].Lo, differ[0].Hi
+	for {
+		parts := a.RangeDigest(lo, hi, 2)
+		if len(parts) == 1 {
+			break
+		}
+		lo, hi = parts[1].Lo, parts[1].Hi
+		if other := b.RangeDigest(parts[0].Lo, parts[0].Hi, 1); len(other) == 0 || other[0].Hash != parts[0].Hash {
+			lo, hi = parts[0].Lo, parts[0].Hi
+		}
+	}
+	if lo != 42 {
+		t.Fatalf("expected to locate 42, got %v", lo)
+	}
+	if !reflect.DeepEqual(a.KeyDigest(1), newIntMap(100).KeyDigest(1)) {
+		t.Fatalf("equal maps should have equal digests")
+	}
+}
+
+// 测试用的int编码方法
+func encodeInt(item interface{}) ([]byte, error) {
+	return binary.AppendVarint(nil, int64(item.(int))), nil
+}
+
+func TestMerkleProof(t *testing.T) {
+	build := func(opts ...rbmap.Option) *rbmap.Map {
+		mp := rbmap.NewMap(intCompare, append(opts, rbmap.WithMerkleEncoder(encodeInt))...)
+		for i := 1; i <= 13; i++ {
+			mp.Add(i, i*10)
+		}
+		return mp
+	}
+	a, b := build(), build()
+	root, err := a.RootHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other, _ := b.RootHash(); other != root {
+		t.Fatalf("maps built the same way should have the same root hash")
+	}
+	for i := 1; i <= 13; i++ {
+		ok, proof, err := a.ProveMembership(i)
+		if !ok || err != nil || !rbmap.VerifyMembership(root, proof, encodeInt) {
+			t.Fatalf("proof for %d should verify", i)
+		}
+		proof.Val = 0
+		if rbmap.VerifyMembership(root, proof, encodeInt) {
+			t.Fatalf("tampered proof for %d should not verify", i)
+		}
+	}
+	b.Set(7, 0)
+	if changed, _ := b.RootHash(); changed == root {
+		t.Fatalf("changed value should change the root hash")
+	}
+	b.Set(7, 70)
+	if restored, _ := b.RootHash(); restored != root {
+		t.Fatalf("restoring the value should restore the root hash")
+	}
+	if ok, _, _ := a.ProveMembership(100); ok {
+		t.Fatalf("missing key should have no proof")
+	}
+
+	// 增删改之后缓存的哈希应该与按照同样形状重新计算的结果相同
+	c := build(rbmap.WithSoftDelete())
+	c.RootHash()
+	for i := 14; i <= 40; i++ {
+		c.Add(i, i)
+	}
+	c.Delete(3)
+	c.Delete(20)
+	c.DeleteRange(30, 33)
+	c.WithValue(5, func(val *interface{}) { *val = 500 })
+	got, err := c.RootHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, _ := c.Export()
+	fresh, _, _ := rbmap.ImportTree(tree, intCompare, rbmap.WithSoftDelete(), rbmap.WithMerkleEncoder(encodeInt))
+	if want, _ := fresh.RootHash(); want != got {
+		t.Fatalf("cached root hash is stale")
+	}
+	ok, proof, _ := c.ProveMembership(5)
+	if !ok || !rbmap.VerifyMembership(got, proof, encodeInt) {
+		t.Fatalf("proof after updates should verify")
+	}
+
+	// 没有设置编码方法时要求实现encoding.BinaryMarshaler
+	if _, err := newIntMap(3).RootHash(); !errors.Is(err, rbmap.ErrNotMarshaler) {
+		t.Fatalf("expected ErrNotMarshaler, got %v", err)
+	}
+}
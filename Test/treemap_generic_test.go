@@ -0,0 +1,83 @@
+package Test
+
+import (
+	"math/rand"
+	"rbtree/rbmap"
+	"strings"
+	"testing"
+)
+
+func TestGenericTreeMap(t *testing.T) {
+	mp := rbmap.NewOrderedTreeMap[string, int]()
+	for i, word := range strings.Fields("pear apple fig banana cherry") {
+		mp.Add(word, i)
+	}
+	if err := mp.Add("fig", 10); err != rbmap.ErrNodeAlreadyExists {
+		t.Fatalf("expected ErrNodeAlreadyExists, got %v", err)
+	}
+	if ok, val := mp.Get("fig"); !ok || val != 10 {
+		t.Fatalf("expected 10, got %d", val)
+	}
+	if ok, val := mp.Get("kiwi"); ok || val != 0 {
+		t.Fatalf("missing key should return the zero value")
+	}
+	var keys []string
+	for pair := range mp.Range() {
+		keys = append(keys, pair.Key)
+	}
+	if strings.Join(keys, " ") != "apple banana cherry fig pear" {
+		t.Fatalf("unexpected order %v", keys)
+	}
+	keys = keys[:0]
+	for key, val := range mp.All() {
+		if key == "cherry" {
+			break
+		}
+		if _, want := mp.Get(key); val != want {
+			t.Fatalf("All returned wrong value for %s", key)
+		}
+		keys = append(keys, key)
+	}
+	if strings.Join(keys, " ") != "apple banana" {
+		t.Fatalf("unexpected All prefix %v", keys)
+	}
+	if !mp.Set("pear", 1) || mp.Set("kiwi", 1) || mp.Delete("kiwi") != rbmap.ErrNodeNotExists {
+		t.Fatalf("unexpected Set/Delete results")
+	}
+}
+
+func TestGenericTreeMapRandom(t *testing.T) {
+	// 与go map比较，并且每一步都检查红黑树的性质
+	r := rand.New(rand.NewSource(5))
+	mp := rbmap.NewTreeMap[int, int](func(a, b int) int { return a - b })
+	expected := map[int]int{}
+	for i := 0; i < 5000; i++ {
+		key := r.Intn(300)
+		if r.Intn(3) == 0 {
+			err := mp.Delete(key)
+			if _, ok := expected[key]; ok != (err == nil) {
+				t.Fatalf("delete %d: unexpected error %v", key, err)
+			}
+			delete(expected, key)
+		} else {
+			mp.Add(key, i)
+			expected[key] = i
+		}
+		if i%50 == 0 {
+			if err := mp.Validate(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := mp.Validate(); err != nil || mp.Len() != len(expected) {
+		t.Fatalf("expected %d entries, got %d: %v", len(expected), mp.Len(), err)
+	}
+	prev := -1
+	mp.ForEach(func(key, val int) bool {
+		if key <= prev || expected[key] != val {
+			t.Fatalf("unexpected entry %d: %d", key, val)
+		}
+		prev = key
+		return true
+	})
+}
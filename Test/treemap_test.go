@@ -0,0 +1,63 @@
+package Test
+
+import (
+	"rbtree/rbmap/treemap"
+	"slices"
+	"testing"
+)
+
+func TestTreeMapAdapter(t *testing.T) {
+	m := treemap.NewWithIntComparator()
+	for _, key := range []int{5, 1, 9, 3, 7} {
+		m.Put(key, key*10)
+	}
+	m.Put(3, 31)
+	m.Remove(9)
+	m.Remove(100)
+	if m.Size() != 4 || m.Empty() {
+		t.Fatalf("expected 4 entries, got %d", m.Size())
+	}
+	if v, ok := m.Get(3); !ok || v != 31 {
+		t.Fatalf("expected 31, got %v", v)
+	}
+	if !slices.Equal(m.Keys(), []interface{}{1, 3, 5, 7}) || !slices.Equal(m.Values(), []interface{}{10, 31, 50, 70}) {
+		t.Fatalf("unexpected keys %v values %v", m.Keys(), m.Values())
+	}
+	if k, _ := m.Floor(4); k != 3 {
+		t.Fatalf("expected floor 3, got %v", k)
+	}
+	if k, _ := m.Ceiling(6); k != 7 {
+		t.Fatalf("expected ceiling 7, got %v", k)
+	}
+	if k, _ := m.Ceiling(8); k != nil {
+		t.Fatalf("expected no ceiling, got %v", k)
+	}
+	if k, _ := m.Min(); k != 1 {
+		t.Fatalf("expected min 1, got %v", k)
+	}
+	if k, _ := m.Max(); k != 7 {
+		t.Fatalf("expected max 7, got %v", k)
+	}
+	if m.String() != "TreeMap\nmap[1:10 3:31 5:50 7:70]" {
+		t.Fatalf("unexpected string %q", m.String())
+	}
+
+	it := m.Iterator()
+	var keys []interface{}
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	for it.Prev() {
+		keys = append(keys, it.Key())
+	}
+	if !slices.Equal(keys, []interface{}{1, 3, 5, 7, 7, 5, 3, 1}) {
+		t.Fatalf("unexpected iteration %v", keys)
+	}
+	if !it.Last() || it.Value() != 70 {
+		t.Fatalf("expected last value 70")
+	}
+	m.Clear()
+	if !m.Empty() || m.Iterator().First() {
+		t.Fatalf("expected empty map")
+	}
+}
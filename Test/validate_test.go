@@ -0,0 +1,57 @@
+package Test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	mp := newIntMap(500)
+	for i := 1; i <= 500; i += 3 {
+		mp.Delete(i)
+	}
+	if err := mp.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackgroundValidation(t *testing.T) {
+	mp := newIntMap(100)
+	var mu sync.Mutex
+	v := mp.StartBackgroundValidation(time.Millisecond, 10, &mu)
+	// 后台校验期间持锁修改
+	for i := 101; i <= 200; i++ {
+		mu.Lock()
+		mp.Add(i, i)
+		mp.Delete(i - 100)
+		mu.Unlock()
+	}
+	time.Sleep(20 * time.Millisecond)
+	v.Stop()
+	if err := v.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMapShutdown(t *testing.T) {
+	mp := newIntMap(100)
+	var mu sync.Mutex
+	first := mp.StartBackgroundValidation(time.Millisecond, 10, &mu)
+	second := mp.StartBackgroundValidation(time.Hour, 10, &mu)
+	// 不合法的间隔和批量大小会被修正，不会panic
+	mp.StartBackgroundValidation(0, 0, &mu)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := mp.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// 后台协程已经退出，Stop立即返回
+	first.Stop()
+	second.Stop()
+	if first.Err() != nil || second.Err() != nil {
+		t.Fatalf("unexpected validation errors")
+	}
+	mp.Close()
+}
@@ -0,0 +1,58 @@
+package Test
+
+import (
+	"rbtree/rbmap"
+	"rbtree/rbmap/oracle"
+	"rbtree/rbmap/workload"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWorkloadDeterministic(t *testing.T) {
+	cfg := workload.Config{Seed: 42, Dist: workload.Zipfian, Keys: 1000, ReadRatio: 0.5, DeleteRatio: 0.1}
+	a, b := workload.New(cfg).Ops(1000), workload.New(cfg).Ops(1000)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("same config should generate the same ops")
+	}
+}
+
+func TestWorkloadCrossCheck(t *testing.T) {
+	for _, dist := range []workload.KeyDist{workload.Uniform, workload.Zipfian, workload.Sequential, workload.TimeBased} {
+		g := workload.New(workload.Config{Seed: int64(dist), Dist: dist, Keys: 200,
+			ReadRatio: 0.3, DeleteRatio: 0.2, Start: time.Unix(0, 0)})
+		if err := oracle.CrossCheck(rbmap.NewMap(intCompare), intCompare, g.Ops(5000)); err != nil {
+			t.Fatalf("dist %d: %v", dist, err)
+		}
+	}
+}
+
+func TestWorkloadDefaultStart(t *testing.T) {
+	// 没有设置Start时从Unix纪元开始
+	cfg := workload.Config{Seed: 1, Dist: workload.TimeBased, Step: time.Second}
+	ops := workload.New(cfg).Ops(100)
+	cfg.Start = time.Unix(0, 0)
+	if !reflect.DeepEqual(ops, workload.New(cfg).Ops(100)) {
+		t.Fatalf("zero Start should default to the Unix epoch")
+	}
+	for _, op := range ops {
+		if key := op.Key.(int); key < 0 || key > 150*int(time.Second) {
+			t.Fatalf("key %d outside the expected range", key)
+		}
+	}
+}
+
+func TestWorkloadExistingKeys(t *testing.T) {
+	// 读和删除操作的key都应该是之前写过的key
+	for _, dist := range []workload.KeyDist{workload.Sequential, workload.TimeBased} {
+		written := map[interface{}]bool{}
+		ops := workload.New(workload.Config{Seed: 7, Dist: dist, ReadRatio: 0.4, DeleteRatio: 0.2}).Ops(2000)
+		for i, op := range ops {
+			if op.Kind == oracle.OpAdd {
+				written[op.Key] = true
+			} else if len(written) > 0 && !written[op.Key] {
+				t.Fatalf("dist %d: op %d uses key %v that was never written", dist, i, op.Key)
+			}
+		}
+	}
+}
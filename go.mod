@@ -1,3 +1,3 @@
 module rbtree
 
-go 1.20
+go 1.23
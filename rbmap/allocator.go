@@ -0,0 +1,102 @@
+package rbmap
+
+import "sync"
+
+// Allocator 节点分配器，可以用来接入对象池、内存池或者带统计的分配器，
+// New返回一个零值节点，树删除节点后调用Free归还，归还后树不会再使用这个节点；
+// 整棵树被替换（例如ReplaceAll、LoadSharded）时旧的节点不会逐个归还，交给垃圾回收处理
+type Allocator interface {
+	New() *Node
+	Free(node *Node)
+}
+
+// WithAllocator 设置节点分配器，不设置时使用DefaultAllocator
+func WithAllocator(alloc Allocator) Option {
+	return func(m *Map) {
+		m.alloc = alloc
+	}
+}
+
+// 获得Map使用的分配器
+func (m *Map) allocator() Allocator {
+	if m.alloc == nil {
+		return DefaultAllocator{}
+	}
+	return m.alloc
+}
+
+// 把从树中删除的节点归还给分配器
+func (m *Map) freeNode(node *Node) {
+	if m.alloc != nil {
+		m.alloc.Free(node)
+	}
+}
+
+// DefaultAllocator 默认的分配器，直接使用new分配，Free什么都不做
+type DefaultAllocator struct{}
+
+// New 分配一个节点
+func (DefaultAllocator) New() *Node {
+	return new(Node)
+}
+
+// Free 什么都不做，节点交给垃圾回收处理
+func (DefaultAllocator) Free(*Node) {}
+
+// PoolAllocator 使用sync.Pool复用节点的分配器，适合频繁插入删除的场景，可以在多个Map之间共享
+type PoolAllocator struct {
+	pool sync.Pool
+}
+
+// NewPoolAllocator 创建使用sync.Pool复用节点的分配器
+func NewPoolAllocator() *PoolAllocator {
+	return &PoolAllocator{pool: sync.Pool{New: func() any { return new(Node) }}}
+}
+
+// New 从池中取出一个节点
+func (p *PoolAllocator) New() *Node {
+	return p.pool.Get().(*Node)
+}
+
+// Free 清空节点后放回池中
+func (p *PoolAllocator) Free(node *Node) {
+	*node = Node{}
+	p.pool.Put(node)
+}
+
+// ArenaAllocator 每次分配一整块节点再逐个使用的分配器，减少分配次数并让节点在内存中更紧凑，
+// 归还的节点在下一次分配时优先复用；不是并发安全的，不能在多个协程使用的Map之间共享
+type ArenaAllocator struct {
+	chunk []Node
+	size  int
+	free  []*Node
+}
+
+// NewArenaAllocator 创建每次分配chunkSize个节点的分配器，chunkSize小于1时使用1024
+func NewArenaAllocator(chunkSize int) *ArenaAllocator {
+	if chunkSize < 1 {
+		chunkSize = 1024
+	}
+	return &ArenaAllocator{size: chunkSize}
+}
+
+// New 优先复用归还的节点，没有时从当前的块中取出一个节点，块用完时分配新的块
+func (a *ArenaAllocator) New() *Node {
+	if n := len(a.free); n > 0 {
+		node := a.free[n-1]
+		a.free = a.free[:n-1]
+		*node = Node{}
+		return node
+	}
+	if len(a.chunk) == 0 {
+		a.chunk = make([]Node, a.size)
+	}
+	node := &a.chunk[0]
+	a.chunk = a.chunk[1:]
+	return node
+}
+
+// Free 记录归还的节点以便复用
+func (a *ArenaAllocator) Free(node *Node) {
+	a.free = append(a.free, node)
+}
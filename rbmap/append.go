@@ -0,0 +1,22 @@
+package rbmap
+
+// AppendMax 添加比树中所有key都大的键值对，适合日志、时间戳等按顺序写入的场景：
+// 缓存最大的节点，直接插入到它的右边而不用从根节点开始查找，省去了查找时的O(log n)次比较，
+// 但是插入后仍然要沿着祖先更新子树大小，所以时间复杂度为O(log n)；
+// key不大于当前最大的key时退化为普通的Add
+func (m *Map) AppendMax(key keyItem, val valItem) error {
+	if err := m.checkKey(key); err != nil {
+		return err
+	}
+	if m.maxNode == nil {
+		m.maxNode = m.root.maximum()
+	}
+	if m.maxNode == nil || m.compareFunc(key, m.maxNode.key) != 2 {
+		return m.Add(key, val)
+	}
+	if err := m.throttle(); err != nil {
+		return err
+	}
+	m.addAt(m.maxNode.right, slot{parent: m.maxNode}, key, val)
+	return nil
+}
@@ -0,0 +1,61 @@
+package rbmap
+
+// 根据key的顺序查找两端以及与给定key相邻的键值对，只需要从根向下查找一次，时间复杂度O(log n)
+
+// Min 获得最小的键值对，没有键值对时返回false
+func (m *Map) Min() (bool, keyItem, valItem) {
+	return entryOf(m.root.minimum().skipForward())
+}
+
+// Max 获得最大的键值对，没有键值对时返回false
+func (m *Map) Max() (bool, keyItem, valItem) {
+	return entryOf(m.root.maximum().skipBackward())
+}
+
+// 返回节点存放的键值对，node为nil时返回false
+func entryOf(node *Node) (bool, keyItem, valItem) {
+	if node == nil {
+		return false, nil, nil
+	}
+	return true, node.key, node.val
+}
+
+// Floor 获得小于等于key的最大键值对，key可以不存在，没有时返回false
+func (m *Map) Floor(key keyItem) (bool, keyItem, valItem) {
+	if m.checkKey(key) != nil {
+		return false, nil, nil
+	}
+	return entryOf(m.floorNode(key).skipBackward())
+}
+
+// Ceiling 获得大于等于key的最小键值对，key可以不存在，没有时返回false
+func (m *Map) Ceiling(key keyItem) (bool, keyItem, valItem) {
+	if m.checkKey(key) != nil {
+		return false, nil, nil
+	}
+	return entryOf(m.ceilingNode(key).skipForward())
+}
+
+// Lower 获得严格小于key的最大键值对，key可以不存在，没有时返回false
+func (m *Map) Lower(key keyItem) (bool, keyItem, valItem) {
+	if m.checkKey(key) != nil {
+		return false, nil, nil
+	}
+	node := m.floorNode(key)
+	if node != nil && m.compareFunc(node.key, key) == 0 {
+		node = node.prev()
+	}
+	return entryOf(node.skipBackward())
+}
+
+// Higher 获得严格大于key的最小键值对，key可以不存在，没有时返回false
+func (m *Map) Higher(key keyItem) (bool, keyItem, valItem) {
+	if m.checkKey(key) != nil {
+		return false, nil, nil
+	}
+	node := m.ceilingNode(key)
+	if node != nil && m.compareFunc(node.key, key) == 0 {
+		node = node.next()
+	}
+	return entryOf(node.skipForward())
+}
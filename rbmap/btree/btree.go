@@ -0,0 +1,178 @@
+// Package btree: 在rbmap之上实现google/btree风格的接口，从google/btree迁移时不需要修改调用的代码
+package btree
+
+import "rbtree/rbmap"
+
+// Item 树中的元素，与google/btree相同，通过Less比较大小，!a.Less(b) && !b.Less(a)时视为相等
+type Item interface {
+	Less(than Item) bool
+}
+
+// ItemIterator 遍历时的回调，返回false时停止遍历
+type ItemIterator func(i Item) bool
+
+// BTree 使用rbmap.Map存放Item的树，key和val都是Item本身
+type BTree struct {
+	m *rbmap.Map
+}
+
+// New 创建空树，degree只是为了与google/btree的签名保持一致，没有作用
+func New(degree int) *BTree {
+	return &BTree{m: rbmap.NewMap(rbmap.LessToCompare(func(a, b Item) bool { return a.Less(b) }))}
+}
+
+// ReplaceOrInsert 添加item，已经存在相等的元素时替换并返回原来的元素，否则返回nil
+func (t *BTree) ReplaceOrInsert(item Item) Item {
+	if item == nil {
+		panic("btree: nil item being added")
+	}
+	var old Item
+	t.m.Update(item, func(val interface{}, exists bool) (interface{}, bool) {
+		if exists {
+			old = val.(Item)
+		}
+		return item, true
+	})
+	return old
+}
+
+// Delete 删除与item相等的元素并返回，不存在时返回nil
+func (t *BTree) Delete(item Item) Item {
+	var old Item
+	t.m.Update(item, func(val interface{}, exists bool) (interface{}, bool) {
+		if exists {
+			old = val.(Item)
+		}
+		return nil, false
+	})
+	return old
+}
+
+// DeleteMin 删除并返回最小的元素，树为空时返回nil
+func (t *BTree) DeleteMin() Item {
+	if item := t.Min(); item != nil {
+		return t.Delete(item)
+	}
+	return nil
+}
+
+// DeleteMax 删除并返回最大的元素，树为空时返回nil
+func (t *BTree) DeleteMax() Item {
+	if item := t.Max(); item != nil {
+		return t.Delete(item)
+	}
+	return nil
+}
+
+// Get 获得与key相等的元素，不存在时返回nil
+func (t *BTree) Get(key Item) Item {
+	if ok, val := t.m.Get(key); ok {
+		return val.(Item)
+	}
+	return nil
+}
+
+// Has 判断是否存在与key相等的元素
+func (t *BTree) Has(key Item) bool {
+	ok, _ := t.m.Get(key)
+	return ok
+}
+
+// Len 获得元素个数
+func (t *BTree) Len() int {
+	return t.m.Len()
+}
+
+// Min 获得最小的元素，树为空时返回nil
+func (t *BTree) Min() Item {
+	var min Item
+	t.Ascend(func(i Item) bool {
+		min = i
+		return false
+	})
+	return min
+}
+
+// Max 获得最大的元素，树为空时返回nil
+func (t *BTree) Max() Item {
+	var max Item
+	t.Descend(func(i Item) bool {
+		max = i
+		return false
+	})
+	return max
+}
+
+// Clear 删除所有元素，addNodesToFreelist只是为了与google/btree的签名保持一致，没有作用
+func (t *BTree) Clear(addNodesToFreelist bool) {
+	t.m.ReplaceAll(rbmap.NewMap(nil))
+}
+
+// Clone 复制一棵树，需要O(n)的时间，之后两棵树互不影响
+func (t *BTree) Clone() *BTree {
+	return &BTree{m: rbmap.FromPairs(t.m.Pairs(), rbmap.LessToCompare(func(a, b Item) bool { return a.Less(b) }))}
+}
+
+// Ascend 按照从小到大的顺序遍历所有元素
+func (t *BTree) Ascend(iterator ItemIterator) {
+	t.m.ForEach(func(key, val interface{}) bool {
+		return iterator(val.(Item))
+	})
+}
+
+// AscendGreaterOrEqual 按照从小到大的顺序遍历大于等于pivot的元素
+func (t *BTree) AscendGreaterOrEqual(pivot Item, iterator ItemIterator) {
+	t.m.ForEachFrom(pivot, func(key, val interface{}) bool {
+		return iterator(val.(Item))
+	})
+}
+
+// AscendLessThan 按照从小到大的顺序遍历小于pivot的元素
+func (t *BTree) AscendLessThan(pivot Item, iterator ItemIterator) {
+	t.m.ForEach(func(key, val interface{}) bool {
+		return key.(Item).Less(pivot) && iterator(val.(Item))
+	})
+}
+
+// AscendRange 按照从小到大的顺序遍历[greaterOrEqual, lessThan)中的元素
+func (t *BTree) AscendRange(greaterOrEqual, lessThan Item, iterator ItemIterator) {
+	t.m.ForEachFrom(greaterOrEqual, func(key, val interface{}) bool {
+		return key.(Item).Less(lessThan) && iterator(val.(Item))
+	})
+}
+
+// Descend 按照从大到小的顺序遍历所有元素
+func (t *BTree) Descend(iterator ItemIterator) {
+	t.m.Descending().ForEach(func(key, val interface{}) bool {
+		return iterator(val.(Item))
+	})
+}
+
+// DescendLessOrEqual 按照从大到小的顺序遍历小于等于pivot的元素
+func (t *BTree) DescendLessOrEqual(pivot Item, iterator ItemIterator) {
+	t.m.Descending().ForEachFrom(pivot, func(key, val interface{}) bool {
+		return iterator(val.(Item))
+	})
+}
+
+// DescendGreaterThan 按照从大到小的顺序遍历大于pivot的元素
+func (t *BTree) DescendGreaterThan(pivot Item, iterator ItemIterator) {
+	t.m.Descending().ForEach(func(key, val interface{}) bool {
+		return pivot.Less(key.(Item)) && iterator(val.(Item))
+	})
+}
+
+// DescendRange 按照从大到小的顺序遍历(greaterThan, lessOrEqual]中的元素
+func (t *BTree) DescendRange(lessOrEqual, greaterThan Item, iterator ItemIterator) {
+	t.m.Descending().ForEachFrom(lessOrEqual, func(key, val interface{}) bool {
+		return greaterThan.Less(key.(Item)) && iterator(val.(Item))
+	})
+}
+
+// Int 实现了Item的int，与google/btree.Int相同
+type Int int
+
+// Less 比较两个Int的大小
+func (a Int) Less(b Item) bool {
+	return a < b.(Int)
+}
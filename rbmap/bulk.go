@@ -0,0 +1,131 @@
+package rbmap
+
+// 根据有序的键值对直接构建平衡的红黑树
+
+// 判断键值对是否按照比较方法严格递增
+func (m *Map) sortedPairs(pairs []Pair) bool {
+	for i := 1; i < len(pairs); i++ {
+		if m.compareFunc(pairs[i-1].Key, pairs[i].Key) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// 用严格递增的键值对替换整棵树，时间复杂度O(n)
+func (m *Map) buildSorted(pairs []Pair) {
+	m.resetCounters()
+	m.root = buildBalanced(len(pairs), func(i int) *Node {
+		node := m.newNode(pairs[i].Key, m.internVal(pairs[i].Val))
+		node.meta = pairs[i].Meta
+		m.indexCounter(node.key, node.val)
+		m.recordKeyType(node.key)
+		node.stamp()
+		m.lruTouch(node)
+		return node
+	}, m.sentinel)
+	m.maxNode = nil
+	m.size, m.tombstones = len(pairs), 0
+}
+
+// 用count个有序的节点构建平衡的红黑树并返回根节点，node(i)返回第i个节点，leaf返回叶子节点
+//
+// 每次取中点作为根节点，这样除了最深的一层以外每一层都是满的，
+// 把不满的最深一层染成红色，其余节点染成黑色，就满足了红黑树的所有性质
+func buildBalanced(count int, node func(i int) *Node, leaf func() *Node) *Node {
+	// 计算满层的层数
+	full := 0
+	for (1<<(full+1))-1 <= count {
+		full++
+	}
+	var build func(lo, hi, depth int) *Node
+	build = func(lo, hi, depth int) *Node {
+		if lo >= hi {
+			return leaf()
+		}
+		mid := int(uint(lo+hi) >> 1)
+		n := node(mid)
+		n.color = depth >= full
+		n.left, n.right = build(lo, mid, depth+1), build(mid+1, hi, depth+1)
+		n.left.parent, n.right.parent = n, n
+		n.updateCount()
+		return n
+	}
+	root := build(0, count, 0)
+	root.parent = nil
+	return root
+}
+
+// 用键值对替换整棵树，键值对严格递增时直接构建，否则依次插入，重复的key以后出现的为准
+func (m *Map) loadPairs(pairs []Pair) {
+	if m.sortedPairs(pairs) {
+		m.buildSorted(pairs)
+		return
+	}
+	m.root, m.size, m.tombstones, m.maxNode = m.sentinel(), 0, 0, nil
+	m.resetCounters()
+	m.AddPairs(pairs)
+}
+
+// FromPairs 根据键值对创建Map，键值对严格递增时在O(n)时间内直接构建，否则依次插入，重复的key以后出现的为准
+func FromPairs(pairs []Pair, compareFunc CompareFunc, opts ...Option) *Map {
+	m := NewMap(compareFunc, opts...)
+	m.loadPairs(pairs)
+	return m
+}
+
+// Pairs 按照从小到大的顺序导出所有键值对
+func (m *Map) Pairs() []Pair {
+	return m.AppendTo(make([]Pair, 0, m.size))
+}
+
+// CloneRange 创建只包含from到to之间（包括两端）键值对的新Map，只遍历这个范围并直接构建平衡的树，
+// 时间复杂度O(log n + k)，k为范围内的键值对个数；新Map使用相同的比较方法，其他配置通过opts传入，
+// key和val只做浅拷贝
+func (m *Map) CloneRange(from, to keyItem, opts ...Option) *Map {
+	clone := NewMap(m.compareFunc, opts...)
+	if m.checkKey(from) != nil || m.checkKey(to) != nil {
+		return clone
+	}
+	var pairs []Pair
+	for node := m.ceilingNode(from).skipForward(); node != nil && m.compareFunc(node.key, to) != 2; node = node.next().skipForward() {
+		pairs = append(pairs, Pair{Key: node.key, Val: node.val, Meta: node.meta})
+	}
+	clone.buildSorted(pairs)
+	return clone
+}
+
+// AddPairs 把键值对合并到Map中，已经存在的key会被覆盖，返回新添加的key的个数
+func (m *Map) AddPairs(pairs []Pair) int {
+	added := 0
+	for _, pair := range pairs {
+		err := m.Add(pair.Key, pair.Val)
+		if err == nil {
+			added++
+		}
+		// Add已经消耗过令牌，元数据直接写入节点，被拒绝的写入不设置元数据
+		if err == nil || err == ErrNodeAlreadyExists {
+			if node := m.lookup(pair.Key); node != nil {
+				node.meta = pair.Meta
+			}
+		}
+	}
+	return added
+}
+
+// UpdateRange 在一次遍历中把from到to之间（包括两端）每个键值对的val替换为fn的返回值，返回更新的个数，
+// 适合批量重新定价、重新分类这类任务，不需要对每个key单独查找；fn中不能修改树
+func (m *Map) UpdateRange(from, to keyItem, fn func(key, val interface{}) interface{}) int {
+	if m.checkKey(from) != nil || m.checkKey(to) != nil {
+		return 0
+	}
+	if m.compareFunc(from, to) == 2 || m.throttleN(m.Rank(to)+btoi(m.lookup(to) != nil)-m.Rank(from)) != nil {
+		return 0
+	}
+	n := 0
+	for node := m.ceilingNode(from).skipForward(); node != nil && m.compareFunc(node.key, to) != 2; node = node.next().skipForward() {
+		m.replaceVal(node, fn(node.key, node.val))
+		n++
+	}
+	return n
+}
@@ -0,0 +1,55 @@
+package rbmap
+
+import "cmp"
+
+// 比较方法相关的辅助函数
+
+// LessToCompare 将"小于"函数转换为CompareFunc，方便直接复用sort.Slice风格或其他树结构库里已有的less函数
+//
+// 传入的key必须是K类型，否则类型断言会panic
+func LessToCompare[K any](less func(a, b K) bool) CompareFunc {
+	return func(a, b interface{}) uint8 {
+		c, d := a.(K), b.(K)
+		if less(c, d) {
+			return 1
+		} else if less(d, c) {
+			return 2
+		}
+		return 0
+	}
+}
+
+// Reverse 返回与compareFunc排序相反的比较方法，用它创建的Map会按照从大到小的顺序存放
+func Reverse(compareFunc CompareFunc) CompareFunc {
+	return func(a, b interface{}) uint8 {
+		switch compareFunc(a, b) {
+		case 1:
+			return 2
+		case 2:
+			return 1
+		default:
+			return 0
+		}
+	}
+}
+
+// Ordered 支持 < 运算符的类型，即标准库的cmp.Ordered
+type Ordered = cmp.Ordered
+
+// OrderedCompare 获得使用 < 运算符比较K类型的CompareFunc，浮点数的NaN与任何值都视为相等，不能作为key
+func OrderedCompare[K Ordered]() CompareFunc {
+	return func(a, b interface{}) uint8 {
+		c, d := a.(K), b.(K)
+		if c < d {
+			return 1
+		} else if c > d {
+			return 2
+		}
+		return 0
+	}
+}
+
+// NewOrderedMap 创建key为K类型的Map，比较方法由 < 运算符自动生成，常见的int、string、float等类型的key不需要再传入比较方法
+func NewOrderedMap[K Ordered](opts ...Option) *Map {
+	return NewMap(OrderedCompare[K](), opts...)
+}
@@ -0,0 +1,57 @@
+package rbmap
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+)
+
+// ErrNoCompressor 读取压缩过的快照但是没有设置压缩方法时报错
+var ErrNoCompressor = errors.New("snapshot is compressed but no compressor is configured")
+
+// Compressor 快照数据的压缩方法，可以接入snappy、zstd等压缩库，需要可以被多个协程同时调用
+type Compressor interface {
+	Compress(src []byte) ([]byte, error)
+	Decompress(src []byte) ([]byte, error)
+}
+
+// WithSnapshotCompression 设置DumpSharded写入快照时使用的压缩方法，LoadSharded读取时需要设置同样的压缩方法，
+// val是JSON等较大的文本时可以大幅减小快照的体积
+func WithSnapshotCompression(c Compressor) Option {
+	return func(m *Map) {
+		m.compressor = c
+	}
+}
+
+// FlateCompressor 使用标准库compress/flate的压缩方法，Level为压缩级别，0时使用flate.DefaultCompression
+type FlateCompressor struct {
+	Level int
+}
+
+// Compress 压缩src
+func (f FlateCompressor) Compress(src []byte) ([]byte, error) {
+	level := f.Level
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(src); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress 解压src
+func (f FlateCompressor) Decompress(src []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(src))
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,155 @@
+package rbmap
+
+import (
+	"container/heap"
+	"errors"
+	"sort"
+)
+
+// ErrNotCounter 计数操作遇到不是int64类型的值时报错
+var ErrNotCounter = errors.New("value is not an int64 counter")
+
+// Increment 把key对应的int64计数加上delta并返回新的值，key不存在时从0开始计数，
+// 已有的值不是int64类型时返回ErrNotCounter并且不修改这个键值对
+func (m *Map) Increment(key keyItem, delta int64) (int64, error) {
+	// 先检查类型，Update中保留原值也会写入（更新代数、脏标记和访问顺序）
+	if node := m.lookup(key); node != nil {
+		if _, ok := node.val.(int64); !ok {
+			return 0, ErrNotCounter
+		}
+	}
+	var result int64
+	err := m.Update(key, func(val interface{}, exists bool) (interface{}, bool) {
+		count, _ := val.(int64)
+		result = count + delta
+		return result, true
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result, nil
+}
+
+// WithCounterIndex 额外维护一个按照int64计数从大到小排序的索引，TopKByValue只需要读取索引的前k项，
+// 代价是每次写入、删除int64的值时多一次O(log n)的索引更新以及每个计数一个索引节点的内存
+func WithCounterIndex() Option {
+	return func(m *Map) {
+		m.counters = newCounterIndex(m)
+	}
+}
+
+// 计数索引的key，按照计数从大到小、计数相同时按照key从小到大排序
+type counterEntry struct {
+	count int64
+	key   keyItem
+}
+
+// 创建m的计数索引，比较key时使用m的比较方法
+func newCounterIndex(m *Map) *Map {
+	return NewMap(func(a, b interface{}) uint8 {
+		c, d := a.(counterEntry), b.(counterEntry)
+		if c.count > d.count {
+			return 1
+		} else if c.count < d.count {
+			return 2
+		}
+		return m.compareFunc(c.key, d.key)
+	})
+}
+
+// 把值为int64的键值对加入计数索引
+func (m *Map) indexCounter(key keyItem, val valItem) {
+	if count, ok := val.(int64); ok && m.counters != nil {
+		m.counters.Add(counterEntry{count: count, key: key}, nil)
+	}
+}
+
+// 把值为int64的键值对从计数索引中删除
+func (m *Map) unindexCounter(key keyItem, val valItem) {
+	if count, ok := val.(int64); ok && m.counters != nil {
+		m.counters.Delete(counterEntry{count: count, key: key})
+	}
+}
+
+// 清空计数索引
+func (m *Map) resetCounters() {
+	if m.counters != nil {
+		m.counters = newCounterIndex(m)
+	}
+}
+
+// TopKByValue 返回int64计数最大的k个键值对，按照计数从大到小排列，计数相同时key小的在前，不是int64类型的值会被跳过；
+// 开启WithCounterIndex时读取索引，时间复杂度为O(k log n)，否则退化为ScanTopKByValue
+func (m *Map) TopKByValue(k int) []Pair {
+	if m.counters == nil {
+		return m.ScanTopKByValue(k)
+	}
+	var result []Pair
+	for entry := m.counters.root.minimum(); entry != nil && len(result) < k; entry = entry.next() {
+		node := m.lookup(entry.key.(counterEntry).key)
+		result = append(result, Pair{Key: node.key, Val: node.val, Meta: node.meta})
+	}
+	return result
+}
+
+// ScanTopKByValue 返回int64计数最大的k个键值对，按照计数从大到小排列，计数相同时key小的在前，
+// 不是int64类型的值会被跳过；不使用计数索引，每次调用都用大小为k的堆扫描整棵树，时间复杂度为O(n log k)
+func (m *Map) ScanTopKByValue(k int) []Pair {
+	if k < 1 {
+		return nil
+	}
+	h := &topKHeap{}
+	for node := m.root.minimum().skipForward(); node != nil; node = node.next().skipForward() {
+		count, ok := node.val.(int64)
+		if !ok {
+			continue
+		}
+		// 按照key的顺序遍历，计数相同时先出现的key更小，所以只有计数更大时才替换堆顶
+		if h.Len() < k {
+			heap.Push(h, topKItem{node: node, count: count, order: h.seq})
+		} else if count > h.items[0].count {
+			h.items[0] = topKItem{node: node, count: count, order: h.seq}
+			heap.Fix(h, 0)
+		}
+		h.seq++
+	}
+	sort.Slice(h.items, func(i, j int) bool {
+		a, b := h.items[i], h.items[j]
+		return a.count > b.count || a.count == b.count && a.order < b.order
+	})
+	result := make([]Pair, len(h.items))
+	for i, item := range h.items {
+		result[i] = Pair{Key: item.node.key, Val: item.node.val, Meta: item.node.meta}
+	}
+	return result
+}
+
+// 堆中的元素，order为按照key排序的序号
+type topKItem struct {
+	node  *Node
+	count int64
+	order int
+}
+
+// 计数最小、计数相同时key最大的元素在堆顶
+type topKHeap struct {
+	items []topKItem
+	seq   int
+}
+
+func (h *topKHeap) Len() int { return len(h.items) }
+
+func (h *topKHeap) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	return a.count < b.count || a.count == b.count && a.order > b.order
+}
+
+func (h *topKHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *topKHeap) Push(x any) { h.items = append(h.items, x.(topKItem)) }
+
+func (h *topKHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
@@ -0,0 +1,164 @@
+package rbmap
+
+import (
+	"context"
+	"math/bits"
+)
+
+// DeleteWhere 从最小的键开始删除pred返回true的键值对，最多删除limit个（limit小于等于0时不限制），
+// 返回删除的个数以及下一次应该开始扫描的key，全部扫描完时返回的key为nil，
+// 之后使用DeleteWhereFrom从返回的key继续，这样大规模的清理可以在请求的时限内分批完成
+//
+// 开启限流时一次为这一批的所有key获取令牌，被限流时不删除任何键值对，返回ErrRateLimited以及这一批的第一个key；
+// 删除失败时返回已经删除的个数、失败的key和错误，从返回的key继续即可。pred中不能修改树
+func (m *Map) DeleteWhere(pred func(key, val interface{}) bool, limit int) (int, keyItem, error) {
+	return m.deleteWhere(m.root.minimum(), pred, limit)
+}
+
+// DeleteWhereFrom 与DeleteWhere相同，但是从第一个大于等于start的键开始扫描
+func (m *Map) DeleteWhereFrom(start keyItem, pred func(key, val interface{}) bool, limit int) (int, keyItem, error) {
+	if err := m.checkKey(start); err != nil {
+		return 0, nil, err
+	}
+	return m.deleteWhere(m.ceilingNode(start), pred, limit)
+}
+
+// 从node开始扫描并删除满足条件的键值对
+func (m *Map) deleteWhere(node *Node, pred func(key, val interface{}) bool, limit int) (int, keyItem, error) {
+	// 删除节点时会移动键值对，所以先记录下要删除的key
+	var keys []keyItem
+	var resume keyItem
+	for node = node.skipForward(); node != nil; node = node.next().skipForward() {
+		if limit > 0 && len(keys) == limit {
+			resume = node.key
+			break
+		}
+		if pred(node.key, node.val) {
+			keys = append(keys, node.key)
+		}
+	}
+	if len(keys) == 0 {
+		return 0, resume, nil
+	}
+	if err := m.throttleN(len(keys)); err != nil {
+		return 0, keys[0], err
+	}
+	removed := 0
+	for _, key := range keys {
+		node, err := m.search(key)
+		if err != nil {
+			return removed, key, err
+		}
+		if !node.isLeaf() && !node.isDeleted() {
+			m.removeAt(node)
+			removed++
+		}
+	}
+	return removed, resume, nil
+}
+
+// DeleteRange 删除lo到hi之间（包括两端）的所有键值对，返回删除的个数
+//
+// 先用Rank在O(log n)时间内算出要删除的个数k，k很小时逐个删除，时间复杂度O(k·log n)；
+// k·log n超过树的大小时直接丢弃区间内的节点，用剩下的节点重新构建平衡的树，时间复杂度O(n)，
+// 剩下的节点和叶子都被复用，它们的访问顺序、元数据和代数都不变
+func (m *Map) DeleteRange(lo, hi keyItem) int {
+	removed, _ := m.DeleteRangeCtx(context.Background(), lo, hi)
+	return removed
+}
+
+// DeleteRangeCtx 与DeleteRange相同，但是定期检查ctx，ctx被取消时停止删除，返回已经删除的个数和ctx.Err()；
+// 重建整棵树时只在修改树之前检查ctx，取消后树保持不变
+func (m *Map) DeleteRangeCtx(ctx context.Context, lo, hi keyItem) (int, error) {
+	if m.checkKey(lo) != nil || m.checkKey(hi) != nil || m.compareFunc(lo, hi) == 2 {
+		return 0, nil
+	}
+	k := m.Rank(hi) + btoi(m.lookup(hi) != nil) - m.Rank(lo)
+	if k == 0 {
+		return 0, nil
+	}
+	if err := m.throttleN(k); err != nil {
+		return 0, err
+	}
+	if m.softDelete || k*bits.Len(uint(m.size)) < m.size+m.tombstones {
+		// 删除节点时会移动键值对，所以先记录下要删除的key
+		keys := make([]keyItem, 0, k)
+		for node := m.ceilingNode(lo).skipForward(); node != nil && m.compareFunc(node.key, hi) != 2; node = node.next().skipForward() {
+			if err := m.pause(ctx, len(keys)); err != nil {
+				return 0, err
+			}
+			keys = append(keys, node.key)
+		}
+		removed := 0
+		for i, key := range keys {
+			if err := m.pause(ctx, i); err != nil {
+				return removed, err
+			}
+			if node, err := m.search(key); err == nil && !node.isLeaf() {
+				m.removeAt(node)
+				removed++
+			}
+		}
+		return removed, nil
+	}
+	if err := m.rebuildWithout(ctx, lo, hi); err != nil {
+		return 0, err
+	}
+	return k, nil
+}
+
+// 丢弃lo到hi之间（包括两端，包括被软删除的节点）的所有节点，用剩下的节点重新构建平衡的树，
+// 遍历节点时ctx被取消或者树的深度超过限制就不修改树并返回错误
+func (m *Map) rebuildWithout(ctx context.Context, lo, hi keyItem) error {
+	var kept, dropped []*Node
+	var err error
+	var walk func(node *Node, depth int)
+	walk = func(node *Node, depth int) {
+		if err != nil {
+			return
+		}
+		if node.isLeaf() {
+			return
+		}
+		if depth == 0 {
+			err = ErrTreeCorrupted
+			return
+		}
+		walk(node.left, depth-1)
+		if err != nil {
+			return
+		}
+		if err = m.pause(ctx, len(kept)+len(dropped)); err != nil {
+			return
+		}
+		if m.compareFunc(node.key, lo) != 1 && m.compareFunc(node.key, hi) != 2 {
+			dropped = append(dropped, node)
+		} else {
+			kept = append(kept, node)
+		}
+		walk(node.right, depth-1)
+	}
+	walk(m.root, m.depthLimit())
+	if err != nil {
+		return err
+	}
+	for _, node := range dropped {
+		if node.isDeleted() {
+			m.tombstones--
+		} else {
+			m.unindexCounter(node.key, node.val)
+			m.lruUnlink(node)
+			m.size--
+		}
+		if node.hasFlag(flagPinned) {
+			m.pinned--
+		}
+		m.releaseVal(node.val)
+		m.freeNode(node)
+	}
+	m.root = buildBalanced(len(kept), func(i int) *Node {
+		return kept[i]
+	}, m.sentinel)
+	m.maxNode = nil
+	return nil
+}
@@ -0,0 +1,72 @@
+package rbmap
+
+// DescendingMap Map的逆序视图，与原Map共享同一棵树，所有操作都委托给原Map，只是遍历顺序相反
+//
+// 这样升序和降序访问都不需要把数据存两份
+type DescendingMap struct {
+	m *Map
+}
+
+// Descending 获得当前Map的逆序视图
+func (m *Map) Descending() *DescendingMap {
+	return &DescendingMap{m: m}
+}
+
+// Ascending 获得视图对应的原Map
+func (d *DescendingMap) Ascending() *Map {
+	return d.m
+}
+
+// Len 获得树的节点个数
+func (d *DescendingMap) Len() int {
+	return d.m.Len()
+}
+
+// Add 添加节点 key, val 如果节点存在就设置val的值并且返回错误
+func (d *DescendingMap) Add(key keyItem, val valItem) error {
+	return d.m.Add(key, val)
+}
+
+// Delete 根据key值删除对应节点， 如果节点不存在返回错误
+func (d *DescendingMap) Delete(key keyItem) error {
+	return d.m.Delete(key)
+}
+
+// Set 设置节点 key的值为val, 如果节点key不存在就返回false, 存在就修改返回true
+func (d *DescendingMap) Set(key keyItem, val valItem) bool {
+	return d.m.Set(key, val)
+}
+
+// Get 通过键值key找到对应的val,如果没有返回false
+func (d *DescendingMap) Get(key keyItem) (bool, valItem) {
+	return d.m.Get(key)
+}
+
+// Range 按照从大到小的顺序遍历红黑树，返回对应键值对，和Map.Range一样需要把通道读完
+func (d *DescendingMap) Range() <-chan Pair {
+	ch := make(chan Pair)
+	depth := d.m.depthLimit()
+	go func() {
+		d.m.ranReverse(d.m.root, ch, depth)
+		close(ch)
+	}()
+	return ch
+}
+
+// 使用chan逆序遍历map，深度超过限制时停止遍历并返回false
+func (m *Map) ranReverse(node *Node, ch chan<- Pair, depth int) bool {
+	if node.isLeaf() {
+		return true
+	}
+	if depth == 0 || !m.ranReverse(node.right, ch, depth-1) {
+		return false
+	}
+	if !node.isDeleted() {
+		ch <- Pair{
+			Key:  node.key,
+			Val:  node.val,
+			Meta: node.meta,
+		}
+	}
+	return m.ranReverse(node.left, ch, depth-1)
+}
@@ -0,0 +1,68 @@
+package rbmap
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+)
+
+// DigestBucket 一段连续key的摘要，Lo和Hi为这一段中最小和最大的key，Hash为这一段所有key按顺序计算的哈希
+type DigestBucket struct {
+	Lo, Hi interface{}
+	Count  int
+	Hash   uint64
+}
+
+// KeyDigest 按照排名把所有key平均分成buckets段，返回每一段的摘要，
+// 两个副本同步时，一方发送自己的摘要，另一方用RangeDigest(Lo, Hi, 1)计算同一段的摘要进行比较，
+// 只对不相同的段用RangeDigest继续细分，这样只需要对数轮就能找到不同的key而不用交换全部的key，
+// key的哈希使用fmt的%T和%v格式计算，两个副本的key类型需要相同
+func (m *Map) KeyDigest(buckets int) []DigestBucket {
+	return m.digest(m.root.minimum(), nil, false, buckets)
+}
+
+// RangeDigest 与KeyDigest相同，但是只计算lo到hi之间（包括两端）的key
+func (m *Map) RangeDigest(lo, hi keyItem, buckets int) []DigestBucket {
+	if m.checkKey(lo) != nil || m.checkKey(hi) != nil {
+		return nil
+	}
+	return m.digest(m.ceilingNode(lo), hi, true, buckets)
+}
+
+// 从node开始计算摘要，bounded为true时只计算不大于hi的key
+func (m *Map) digest(node *Node, hi keyItem, bounded bool, buckets int) []DigestBucket {
+	if buckets < 1 {
+		return nil
+	}
+	var nodes []*Node
+	for node = node.skipForward(); node != nil; node = node.next().skipForward() {
+		if bounded && m.compareFunc(node.key, hi) == 2 {
+			break
+		}
+		nodes = append(nodes, node)
+	}
+	if buckets > len(nodes) {
+		buckets = len(nodes)
+	}
+	result := make([]DigestBucket, 0, buckets)
+	for i, start := 0, 0; i < buckets; i++ {
+		count := len(nodes)/buckets + btoi(i < len(nodes)%buckets)
+		h := fnv.New64a()
+		for _, node := range nodes[start : start+count] {
+			writeItem(h, node.key)
+		}
+		result = append(result, DigestBucket{
+			Lo:    nodes[start].key,
+			Hi:    nodes[start+count-1].key,
+			Count: count,
+			Hash:  h.Sum64(),
+		})
+		start += count
+	}
+	return result
+}
+
+// 把key或val写入哈希，带上类型并用0分隔，避免不同的内容拼接后相同
+func writeItem(w io.Writer, item interface{}) {
+	fmt.Fprintf(w, "%T:%v\x00", item, item)
+}
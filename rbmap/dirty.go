@@ -0,0 +1,52 @@
+package rbmap
+
+// 脏标记，用于在树上实现写回(write-behind)缓存
+
+// MarkDirty 将节点key标记为脏，如果节点key不存在就返回false
+func (m *Map) MarkDirty(key keyItem) bool {
+	node := m.lookup(key)
+	if node == nil || m.throttle() != nil {
+		return false
+	}
+	node.setFlag(flagDirty, true)
+	return true
+}
+
+// IsDirty 判断节点key是否被标记为脏
+func (m *Map) IsDirty(key keyItem) bool {
+	node := m.lookup(key)
+	return node != nil && node.hasFlag(flagDirty)
+}
+
+// RangeDirty 按照中序遍历的方式返回所有被标记为脏的键值对，和Range一样需要把通道读完
+func (m *Map) RangeDirty() <-chan Pair {
+	ch := make(chan Pair)
+	go func() {
+		for node := m.root.minimum().skipForward(); node != nil; node = node.next().skipForward() {
+			if node.hasFlag(flagDirty) {
+				ch <- Pair{Key: node.key, Val: node.val, Meta: node.meta}
+			}
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+// FlushDirty 按照中序遍历的方式把脏键值对依次交给fn处理，fn返回nil时清除该键值对的脏标记，
+// fn返回错误时停止遍历并返回该错误，未处理的键值对保持为脏，返回成功清除的个数
+//
+// fn中不能修改树的结构
+func (m *Map) FlushDirty(fn func(key, val interface{}) error) (int, error) {
+	flushed := 0
+	for node := m.root.minimum().skipForward(); node != nil; node = node.next().skipForward() {
+		if !node.hasFlag(flagDirty) {
+			continue
+		}
+		if err := fn(node.key, node.val); err != nil {
+			return flushed, err
+		}
+		node.setFlag(flagDirty, false)
+		flushed++
+	}
+	return flushed, nil
+}
@@ -0,0 +1,402 @@
+package rbmap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// 快照的读写，键值对使用encoding/gob编码，自定义的key和val类型需要先调用gob.Register注册
+
+// 分片文件名格式
+const shardFilePattern = "shard-%06d.gob"
+
+// 分片文件中每一块的键值对个数
+const shardChunkSize = 1024
+
+// 分片文件中的一块键值对，Data为这些键值对单独的gob编码（Compressed为true时经过压缩，Encrypted为true时再经过加密），
+// Sum为Data的CRC32校验和；加密时最后一块为End为true的结束块，Count为整个分片的键值对个数，Data为空数据加密的结果
+type shardChunk struct {
+	Lo, Hi     interface{}
+	Count      int
+	Compressed bool
+	Encrypted  bool
+	End        bool
+	Data       []byte
+	Sum        uint32
+}
+
+var (
+	// 开启加密时读到未加密的块，可能是攻击者替换进来的明文
+	errPlainChunk = errors.New("unencrypted chunk in encrypted snapshot")
+	// 开启加密时没有读到结束块，文件被截断
+	errTruncated = errors.New("missing end of shard marker")
+	// 结束块之后还有数据
+	errAfterEnd = errors.New("data after end of shard marker")
+)
+
+// ChecksumError 读取快照时发现损坏的块，Lo和Hi为这一块中最小和最大的key，
+// After为同一个分片文件中前一块最大的key（第一块损坏时为nil），块的头部无法解析或者快照经过加密时Lo和Hi为nil，
+// 这样加载失败时可以知道具体是哪一段key损坏了
+type ChecksumError struct {
+	File   string
+	Chunk  int
+	Lo, Hi interface{}
+	After  interface{}
+	Err    error
+}
+
+func (e *ChecksumError) Error() string {
+	if e.Lo == nil {
+		return fmt.Sprintf("rbmap: %s: chunk %d after key %v is corrupt: %v", e.File, e.Chunk, e.After, e.Err)
+	}
+	return fmt.Sprintf("rbmap: %s: chunk %d with keys %v to %v is corrupt", e.File, e.Chunk, e.Lo, e.Hi)
+}
+
+func (e *ChecksumError) Unwrap() error {
+	return e.Err
+}
+
+// DumpSharded 按照key的顺序把树平均分成shards份，并发写入dir目录下的分片文件中，
+// 在多核机器上可以大幅缩短大树的快照时间，写入期间不能修改树
+//
+// 分片先写入dir旁边的临时目录，全部写入成功后才用重命名替换dir，写入失败时dir中原来的快照保持不变；
+// dir中不是分片文件的其它文件会被移动到新的目录中
+func (m *Map) DumpSharded(dir string, shards int) error {
+	return m.DumpShardedCtx(context.Background(), dir, shards)
+}
+
+// DumpShardedCtx 与DumpSharded相同，但是写入时定期检查ctx，ctx被取消时停止写入并返回ctx.Err()，
+// 此时dir保持不变，配合WithYieldEvery可以让后台快照不长时间占住处理器
+func (m *Map) DumpShardedCtx(ctx context.Context, dir string, shards int) error {
+	if shards < 1 {
+		shards = 1
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return err
+	}
+	// 临时目录和dir在同一个目录下，保证可以直接重命名
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+".tmp-")
+	if err != nil {
+		return err
+	}
+	// MkdirTemp创建的目录只有所有者可以访问，和MkdirAll创建的目录保持一致
+	if err := os.Chmod(tmp, 0o755); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := m.writeShards(ctx, tmp, shards); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := replaceDir(tmp, dir); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	return nil
+}
+
+// 把shards个分片文件并发写入dir
+func (m *Map) writeShards(ctx context.Context, dir string, shards int) error {
+	// 找到每个分片的第一个节点
+	starts, counts := make([]*Node, shards), make([]int, shards)
+	node := m.root.minimum().skipForward()
+	for i := 0; i < shards; i++ {
+		counts[i] = m.size/shards + btoi(i < m.size%shards)
+		starts[i] = node
+		for j := 0; j < counts[i]; j++ {
+			node = node.next().skipForward()
+		}
+	}
+	errs := make([]error, shards)
+	var wg sync.WaitGroup
+	for i := 0; i < shards; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = m.writeShard(ctx, filepath.Join(dir, fmt.Sprintf(shardFilePattern, i)), starts[i], counts[i])
+		}(i)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// 用写好的tmp目录替换dir：先把dir移到一边，再把tmp重命名为dir，最后把dir中不是分片文件的文件移回来并删除旧目录，
+// 任何时刻都至少有一份完整的快照，替换失败时恢复原来的dir
+func replaceDir(tmp, dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return os.Rename(tmp, dir)
+	} else if err != nil {
+		return err
+	}
+	old := tmp + ".old"
+	if err := os.Rename(dir, old); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		os.Rename(old, dir)
+		return err
+	}
+	entries, err := os.ReadDir(old)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !isShardFile(entry.Name()) {
+			if err := os.Rename(filepath.Join(old, entry.Name()), filepath.Join(dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return os.RemoveAll(old)
+}
+
+// 判断文件名是否为shardFilePattern格式的分片文件
+func isShardFile(name string) bool {
+	var i int
+	if n, err := fmt.Sscanf(name, shardFilePattern, &i); err != nil || n != 1 {
+		return false
+	}
+	return fmt.Sprintf(shardFilePattern, i) == name
+}
+
+// 按照分片顺序获得dir中所有的分片文件，不匹配shardFilePattern的文件被忽略
+func shardFiles(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "shard-*.gob"))
+	if err != nil {
+		return nil, err
+	}
+	files := matches[:0]
+	for _, file := range matches {
+		if isShardFile(filepath.Base(file)) {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// LoadSharded 并发读取DumpSharded写入的分片文件，创建新的Map，
+// 分片文件中的每一块在读取时校验，损坏时返回*ChecksumError，可以用errors.As获得损坏的key范围
+func LoadSharded(dir string, compareFunc CompareFunc, opts ...Option) (*Map, error) {
+	files, err := shardFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("rbmap: no shard files in %s", dir)
+	}
+	m := NewMap(compareFunc, opts...)
+	// Glob返回的文件名已经排好序，和分片顺序一致
+	shards, errs := make([][]Pair, len(files)), make([]error, len(files))
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		go func(i int, file string) {
+			defer wg.Done()
+			shards[i], errs[i] = m.readShard(file)
+		}(i, file)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	total := 0
+	for _, shard := range shards {
+		total += len(shard)
+	}
+	pairs := make([]Pair, 0, total)
+	for _, shard := range shards {
+		pairs = append(pairs, shard...)
+	}
+	m.loadPairs(pairs)
+	return m, nil
+}
+
+// VerifySharded 只校验dir目录下的分片文件而不创建Map，返回所有损坏的块对应的*ChecksumError，
+// 写入时使用了压缩等配置时需要传入相同的opts
+func VerifySharded(dir string, opts ...Option) error {
+	files, err := shardFiles(dir)
+	if err != nil {
+		return err
+	}
+	m := NewMap(nil, opts...)
+	errs := make([]error, len(files))
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		go func(i int, file string) {
+			defer wg.Done()
+			_, errs[i] = m.readShard(file)
+		}(i, file)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// 从start开始把count个键值对写入文件
+func (m *Map) writeShard(ctx context.Context, file string, start *Node, count int) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	if err = m.writeChunks(ctx, bw, file, start, count); err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// 从start开始把count个键值对写入w，每shardChunkSize个键值对作为一块单独编码并计算校验和，
+// name为写入的文件名，开启加密时作为附加数据参与认证
+func (m *Map) writeChunks(ctx context.Context, w io.Writer, name string, start *Node, count int) error {
+	enc := gob.NewEncoder(w)
+	pairs := make([]Pair, 0, min(count, shardChunkSize))
+	node, done, index := start, 0, 0
+	for ; count > 0; index++ {
+		pairs = pairs[:0]
+		for ; count > 0 && len(pairs) < shardChunkSize; node, count = node.next().skipForward(), count-1 {
+			if err := m.pause(ctx, done); err != nil {
+				return err
+			}
+			pairs = append(pairs, Pair{Key: node.key, Val: node.val, Meta: node.meta})
+			done++
+		}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(pairs); err != nil {
+			return err
+		}
+		data := buf.Bytes()
+		var err error
+		if m.compressor != nil {
+			if data, err = m.compressor.Compress(data); err != nil {
+				return err
+			}
+		}
+		if m.aead != nil {
+			if data, err = m.seal(data, chunkAD(name, index, len(pairs), false)); err != nil {
+				return err
+			}
+		}
+		chunk := shardChunk{
+			Count:      len(pairs),
+			Compressed: m.compressor != nil,
+			Encrypted:  m.aead != nil,
+			Data:       data,
+			Sum:        crc32.ChecksumIEEE(data),
+		}
+		if !chunk.Encrypted {
+			// 加密时不写入明文的key范围
+			chunk.Lo, chunk.Hi = pairs[0].Key, pairs[len(pairs)-1].Key
+		}
+		if err = enc.Encode(chunk); err != nil {
+			return err
+		}
+	}
+	if m.aead == nil {
+		return nil
+	}
+	// 经过认证的结束块，读取时没有读到它说明文件被截断了
+	data, err := m.seal(nil, chunkAD(name, index, done, true))
+	if err != nil {
+		return err
+	}
+	return enc.Encode(shardChunk{Count: done, Encrypted: true, End: true, Data: data, Sum: crc32.ChecksumIEEE(data)})
+}
+
+// 读取分片文件中的所有键值对
+func (m *Map) readShard(file string) ([]Pair, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return m.readChunks(bufio.NewReader(f), file)
+}
+
+// 读取writeChunks写入的所有键值对，每读完一块就校验一块，遇到损坏的块时返回*ChecksumError，
+// name为写入时的文件名，用于认证和错误信息
+func (m *Map) readChunks(r io.Reader, name string) ([]Pair, error) {
+	dec := gob.NewDecoder(r)
+	var pairs []Pair
+	var after keyItem
+	ended := false
+	for i := 0; ; i++ {
+		var chunk shardChunk
+		if err := dec.Decode(&chunk); err == io.EOF {
+			if m.aead != nil && !ended {
+				return nil, &ChecksumError{File: name, Chunk: i, After: after, Err: errTruncated}
+			}
+			return pairs, nil
+		} else if err != nil {
+			return nil, &ChecksumError{File: name, Chunk: i, After: after, Err: err}
+		}
+		corrupt := &ChecksumError{File: name, Chunk: i, Lo: chunk.Lo, Hi: chunk.Hi, After: after}
+		if ended {
+			corrupt.Err = errAfterEnd
+			return nil, corrupt
+		}
+		if crc32.ChecksumIEEE(chunk.Data) != chunk.Sum {
+			return nil, corrupt
+		}
+		data := chunk.Data
+		if chunk.Encrypted {
+			if m.aead == nil {
+				return nil, fmt.Errorf("rbmap: read %s: %w", name, ErrNoCipher)
+			}
+			if data, corrupt.Err = m.open(data, chunkAD(name, i, chunk.Count, chunk.End)); corrupt.Err != nil {
+				return nil, corrupt
+			}
+		} else if m.aead != nil || chunk.End {
+			// 开启加密时只接受经过认证的块，否则攻击者可以用明文和匹配的CRC32替换任意一块
+			corrupt.Err = errPlainChunk
+			return nil, corrupt
+		}
+		if chunk.End {
+			if chunk.Count != len(pairs) {
+				return nil, corrupt
+			}
+			ended = true
+			continue
+		}
+		if chunk.Compressed {
+			if m.compressor == nil {
+				return nil, fmt.Errorf("rbmap: read %s: %w", name, ErrNoCompressor)
+			}
+			if data, corrupt.Err = m.compressor.Decompress(data); corrupt.Err != nil {
+				return nil, corrupt
+			}
+		}
+		var part []Pair
+		if corrupt.Err = gob.NewDecoder(bytes.NewReader(data)).Decode(&part); corrupt.Err != nil || len(part) != chunk.Count {
+			return nil, corrupt
+		}
+		if len(part) > 0 {
+			after = part[len(part)-1].Key
+		}
+		pairs = append(pairs, part...)
+	}
+}
+
+// bool转int
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,45 @@
+package rbmap
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// ErrNoCipher 读取加密过的快照但是没有设置加密方法时报错
+var ErrNoCipher = errors.New("snapshot is encrypted but no cipher is configured")
+
+// WithSnapshotEncryption 设置DumpSharded写入快照时使用的AEAD加密方法（例如AES-GCM），LoadSharded读取时需要设置同样的方法，
+// 每一块数据使用随机的nonce单独加密，同时开启压缩时先压缩后加密，加密时快照中不会写入明文的key范围；
+// 文件名、块的序号和键值对个数作为附加数据参与认证，每个分片最后写入一个经过认证的结束块，
+// 所以块被调换、在分片之间重放、文件被截断或者混入未加密的块时读取都会返回*ChecksumError
+func WithSnapshotEncryption(aead cipher.AEAD) Option {
+	return func(m *Map) {
+		m.aead = aead
+	}
+}
+
+// 加密data，nonce放在密文前面，ad为需要一起认证的附加数据
+func (m *Map) seal(data, ad []byte) ([]byte, error) {
+	nonce := make([]byte, m.aead.NonceSize(), m.aead.NonceSize()+len(data)+m.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return m.aead.Seal(nonce, nonce, data, ad), nil
+}
+
+// 解密seal加密的数据，数据或者附加数据被篡改、密钥不对时返回错误
+func (m *Map) open(data, ad []byte) ([]byte, error) {
+	size := m.aead.NonceSize()
+	if len(data) < size {
+		return nil, errors.New("rbmap: encrypted chunk too short")
+	}
+	return m.aead.Open(nil, data[:size], data[size:], ad)
+}
+
+// 一块数据的附加数据：文件名（不含目录）、块的序号、键值对个数以及是否为结束块
+func chunkAD(name string, index, count int, end bool) []byte {
+	return fmt.Appendf(nil, "%s\x00%d\x00%d\x00%t", filepath.Base(name), index, count, end)
+}
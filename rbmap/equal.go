@@ -0,0 +1,47 @@
+package rbmap
+
+import "reflect"
+
+// ValueEqualFunc 判断两个val是否相等的方法
+type ValueEqualFunc func(a, b interface{}) bool
+
+// WithValueEqual 设置判断val相等的方法，Equal和CompareAndSwap会使用它，不设置时默认使用reflect.DeepEqual
+func WithValueEqual(valueEqual ValueEqualFunc) Option {
+	return func(m *Map) {
+		m.valueEqual = valueEqual
+	}
+}
+
+// Equal 判断两个Map是否存放了相同的键值对，key使用当前Map的比较方法比较，val使用当前Map的相等方法比较
+func (m *Map) Equal(other *Map) bool {
+	if m.Len() != other.Len() {
+		return false
+	}
+	a, b := m.root.minimum().skipForward(), other.root.minimum().skipForward()
+	for a != nil && b != nil {
+		if m.compareFunc(a.key, b.key) != 0 || !m.valEqual(a.val, b.val) {
+			return false
+		}
+		a, b = a.next().skipForward(), b.next().skipForward()
+	}
+	return a == nil && b == nil
+}
+
+// CompareAndSwap 当key存在并且对应的val与old相等时把val设置为new并返回true，否则返回false
+func (m *Map) CompareAndSwap(key keyItem, old, new valItem) bool {
+	node := m.lookup(key)
+	if node == nil || !m.valEqual(node.val, old) || m.throttle() != nil {
+		return false
+	}
+	m.replaceVal(node, new)
+	m.lruTouch(node)
+	return true
+}
+
+// 使用设置的相等方法判断两个val是否相等
+func (m *Map) valEqual(a, b valItem) bool {
+	if m.valueEqual == nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return m.valueEqual(a, b)
+}
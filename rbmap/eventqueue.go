@@ -0,0 +1,74 @@
+package rbmap
+
+// EventQueue 扫描线算法使用的事件队列，按照位置从小到大取出事件，同一位置的事件按照加入的顺序取出，
+// 也可以一次取出同一位置的所有事件
+type EventQueue struct {
+	m     *Map
+	count int
+}
+
+// NewEventQueue 创建事件队列，compareFunc用于比较事件的位置
+func NewEventQueue(compareFunc CompareFunc, opts ...Option) *EventQueue {
+	return &EventQueue{m: NewMap(compareFunc, opts...)}
+}
+
+// AddEvent 在位置pos加入事件
+func (q *EventQueue) AddEvent(pos, event interface{}) error {
+	err := q.m.Update(pos, func(val interface{}, exists bool) (interface{}, bool) {
+		if !exists {
+			return []interface{}{event}, true
+		}
+		return append(val.([]interface{}), event), true
+	})
+	if err == nil {
+		q.count++
+	}
+	return err
+}
+
+// Peek 获得下一个事件的位置，队列为空时返回false
+func (q *EventQueue) Peek() (bool, interface{}) {
+	node := q.m.root.minimum().skipForward()
+	if node == nil {
+		return false, nil
+	}
+	return true, node.key
+}
+
+// PopNext 取出位置最小的事件中最早加入的一个，队列为空或者被限流时返回false
+func (q *EventQueue) PopNext() (bool, interface{}, interface{}) {
+	node := q.m.root.minimum().skipForward()
+	if node == nil || q.m.throttle() != nil {
+		return false, nil, nil
+	}
+	pos, events := node.key, node.val.([]interface{})
+	if len(events) == 1 {
+		q.m.removeAt(node)
+	} else {
+		q.m.replaceVal(node, events[1:])
+	}
+	q.count--
+	return true, pos, events[0]
+}
+
+// PopBatch 取出位置最小的所有事件，按照加入的顺序排列，队列为空或者被限流时返回false
+func (q *EventQueue) PopBatch() (bool, interface{}, []interface{}) {
+	node := q.m.root.minimum().skipForward()
+	if node == nil || q.m.throttle() != nil {
+		return false, nil, nil
+	}
+	pos, events := node.key, node.val.([]interface{})
+	q.m.removeAt(node)
+	q.count -= len(events)
+	return true, pos, events
+}
+
+// Len 获得队列中事件的个数
+func (q *EventQueue) Len() int {
+	return q.count
+}
+
+// Positions 获得队列中不同位置的个数
+func (q *EventQueue) Positions() int {
+	return q.m.Len()
+}
@@ -0,0 +1,193 @@
+package rbmap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TreeNode 导出的树节点，用于程序分析、测试用的golden文件以及自定义的可视化工具
+type TreeNode struct {
+	Key         interface{}
+	Value       interface{}
+	Color       bool // RED 或 BLACK
+	Deleted     bool // 是否已被软删除
+	Left, Right *TreeNode
+}
+
+// Export 导出整棵树的结构，叶子节点导出为nil，空树返回nil，树的深度超过限制时返回ErrTreeCorrupted
+func (m *Map) Export() (*TreeNode, error) {
+	return exportNode(m.root, m.depthLimit())
+}
+
+// 递归导出以node为根的子树，最多向下depth层
+func exportNode(node *Node, depth int) (*TreeNode, error) {
+	if node.isLeaf() {
+		return nil, nil
+	}
+	if depth == 0 {
+		return nil, ErrTreeCorrupted
+	}
+	left, err := exportNode(node.left, depth-1)
+	if err != nil {
+		return nil, err
+	}
+	right, err := exportNode(node.right, depth-1)
+	if err != nil {
+		return nil, err
+	}
+	return &TreeNode{
+		Key:     node.key,
+		Value:   node.val,
+		Color:   node.color,
+		Deleted: node.isDeleted(),
+		Left:    left,
+		Right:   right,
+	}, nil
+}
+
+// ImportTree 根据导出的树结构创建Map，与Export配合可以通过外部工具或测试数据往返树结构
+//
+// 如果传入的树满足红黑树性质并且按照compareFunc严格有序，就直接采用这个结构并返回true；
+// 否则按照中序遍历的顺序重新插入所有键值对构建一棵新树并返回false，重复的key以后出现的为准；
+// 重新插入时Add返回的错误（例如被限流、key的类型不一致）会直接返回，同一个节点出现多次（包括有环）时返回ErrTreeCorrupted
+func ImportTree(root *TreeNode, compareFunc CompareFunc, opts ...Option) (*Map, bool, error) {
+	m := NewMap(compareFunc, opts...)
+	if m.validTree(root) {
+		m.root = m.importNode(root)
+		m.root.parent = nil
+		return m, true, nil
+	}
+	// 用栈按中序遍历，树可能很深甚至有环，不能递归
+	visited := make(map[*TreeNode]bool)
+	var stack []*TreeNode
+	for node := root; node != nil || len(stack) > 0; {
+		for ; node != nil; node = node.Left {
+			if visited[node] {
+				return nil, false, fmt.Errorf("%w: node %v appears more than once", ErrTreeCorrupted, node.Key)
+			}
+			visited[node] = true
+			stack = append(stack, node)
+		}
+		node = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !node.Deleted {
+			if err := m.Add(node.Key, node.Value); err != nil && err != ErrNodeAlreadyExists {
+				return nil, false, err
+			}
+		}
+		node = node.Right
+	}
+	return m, false, nil
+}
+
+// 递归创建以node为根的子树，并统计节点个数
+func (m *Map) importNode(node *TreeNode) *Node {
+	if node == nil {
+		return m.sentinel()
+	}
+	n := m.newNode(node.Key, m.internVal(node.Value))
+	n.color = node.Color
+	n.left, n.right = m.importNode(node.Left), m.importNode(node.Right)
+	n.left.parent, n.right.parent = n, n
+	if node.Deleted {
+		n.setFlag(flagDeleted, true)
+		m.tombstones++
+	} else {
+		m.indexCounter(n.key, n.val)
+		m.lruTouch(n)
+		m.size++
+	}
+	n.updateCount()
+	return n
+}
+
+// 可以直接采用的导出树的最大深度，红黑树的深度不超过黑高的两倍，int能表示的节点个数对应的深度不超过128，
+// 更深的树（包括有环的树）一定不满足性质
+const maxImportDepth = 128
+
+// 判断导出的树是否满足红黑树性质并且严格有序
+func (m *Map) validTree(root *TreeNode) bool {
+	if root == nil {
+		return true
+	}
+	if root.Color != BLACK {
+		return false
+	}
+	var last *TreeNode
+	var check func(node *TreeNode, parentRed bool, depth int) int
+	// 返回子树的黑高，不满足性质或者超过depth层时返回-1；同一个节点出现两次时不满足严格有序，所以不会重复遍历
+	check = func(node *TreeNode, parentRed bool, depth int) int {
+		if node == nil {
+			return 1
+		}
+		if depth == 0 || parentRed && node.Color == RED {
+			return -1
+		}
+		l := check(node.Left, node.Color, depth-1)
+		if l < 0 || m.checkKey(node.Key) != nil || last != nil && m.compareFunc(last.Key, node.Key) != 1 {
+			return -1
+		}
+		m.recordKeyType(node.Key)
+		last = node
+		r := check(node.Right, node.Color, depth-1)
+		if r != l {
+			return -1
+		}
+		if node.Color == BLACK {
+			l++
+		}
+		return l
+	}
+	return check(root, false, maxImportDepth) > 0
+}
+
+// WriteMermaid 把树结构输出成Mermaid流程图，红色和黑色节点使用不同样式，
+// 边上的L和R表示左右儿子，叶子节点不输出，可以直接粘贴到支持Mermaid的issue和wiki中，树的深度超过限制时返回ErrTreeCorrupted
+func (m *Map) WriteMermaid(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "flowchart TD")
+	fmt.Fprintln(bw, "    classDef red fill:#d33,stroke:#900,color:#fff")
+	fmt.Fprintln(bw, "    classDef black fill:#222,stroke:#000,color:#fff")
+	id := 0
+	var write func(node *Node, depth int) (int, error)
+	// 输出以node为根的子树，返回node的编号，最多向下depth层
+	write = func(node *Node, depth int) (int, error) {
+		if depth == 0 {
+			return 0, ErrTreeCorrupted
+		}
+		self := id
+		id++
+		class := "black"
+		if node.isRed() {
+			class = "red"
+		}
+		label := strings.ReplaceAll(fmt.Sprint(node.key), `"`, "#quot;")
+		if node.isDeleted() {
+			label += " (deleted)"
+		}
+		fmt.Fprintf(bw, "    n%d[\"%s\"]:::%s\n", self, label, class)
+		if !node.left.isLeaf() {
+			left, err := write(node.left, depth-1)
+			if err != nil {
+				return 0, err
+			}
+			fmt.Fprintf(bw, "    n%d -->|L| n%d\n", self, left)
+		}
+		if !node.right.isLeaf() {
+			right, err := write(node.right, depth-1)
+			if err != nil {
+				return 0, err
+			}
+			fmt.Fprintf(bw, "    n%d -->|R| n%d\n", self, right)
+		}
+		return self, nil
+	}
+	if !m.root.isLeaf() {
+		if _, err := write(m.root, m.depthLimit()); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
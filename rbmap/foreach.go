@@ -0,0 +1,96 @@
+package rbmap
+
+// 基于回调的遍历，回调返回false时立即停止，不需要开启协程和通道
+
+// ForEach 按照从小到大的顺序依次对键值对调用fn，fn返回false时停止遍历
+//
+// fn中不能修改树的结构
+func (m *Map) ForEach(fn func(key, val interface{}) bool) {
+	for node := m.root.minimum().skipForward(); node != nil && fn(node.key, node.val); node = node.next().skipForward() {
+	}
+}
+
+// ForEachFrom 从第一个大于等于startKey的键开始，按照从小到大的顺序依次对键值对调用fn，fn返回false时停止遍历
+//
+// 用于大树上可以被打断的长时间扫描：记录下fn返回false时的key，之后用它调用ForEachFrom就能从这个key继续扫描，
+// 不需要再从最小的键开始遍历
+func (m *Map) ForEachFrom(startKey keyItem, fn func(key, val interface{}) bool) {
+	if m.checkKey(startKey) != nil {
+		return
+	}
+	for node := m.ceilingNode(startKey).skipForward(); node != nil && fn(node.key, node.val); node = node.next().skipForward() {
+	}
+}
+
+// ForEach 按照从大到小的顺序依次对键值对调用fn，fn返回false时停止遍历
+func (d *DescendingMap) ForEach(fn func(key, val interface{}) bool) {
+	for node := d.m.root.maximum().skipBackward(); node != nil && fn(node.key, node.val); node = node.prev().skipBackward() {
+	}
+}
+
+// ForEachFrom 从第一个小于等于startKey的键开始，按照从大到小的顺序依次对键值对调用fn，fn返回false时停止遍历
+func (d *DescendingMap) ForEachFrom(startKey keyItem, fn func(key, val interface{}) bool) {
+	if d.m.checkKey(startKey) != nil {
+		return
+	}
+	for node := d.m.floorNode(startKey).skipBackward(); node != nil && fn(node.key, node.val); node = node.prev().skipBackward() {
+	}
+}
+
+// 寻找大于等于key的最小节点，没有时返回nil
+func (m *Map) ceilingNode(key keyItem) *Node {
+	return m.ceilingIn(m.root, key)
+}
+
+// 从小于key的节点from开始寻找大于等于key的最小节点，没有时返回nil
+//
+// 先向上找到包含答案的最小子树再向下查找，时间复杂度为O(log d)，d为from与答案之间的节点个数，
+// 所以依次查找越来越大的key时，相邻的两次查找几乎不需要比较
+func (m *Map) ceilingFrom(from *Node, key keyItem) *Node {
+	node := from
+	for !node.isRoot() {
+		// node是右儿子时父节点比from小，一定比key小，只有node是左儿子时才需要比较
+		if node.isLeft() && m.compareFunc(key, node.parent.key) != 2 {
+			if result := m.ceilingIn(node, key); result != nil {
+				return result
+			}
+			return node.parent
+		}
+		node = node.parent
+	}
+	return m.ceilingIn(node, key)
+}
+
+// 在以root为根的子树中寻找大于等于key的最小节点，没有时返回nil
+func (m *Map) ceilingIn(root *Node, key keyItem) *Node {
+	var result *Node
+	for node := root; !node.isLeaf(); {
+		switch m.compareFunc(key, node.key) {
+		case 0:
+			return node
+		case 1:
+			result = node
+			node = node.left
+		default:
+			node = node.right
+		}
+	}
+	return result
+}
+
+// 寻找小于等于key的最大节点，没有时返回nil
+func (m *Map) floorNode(key keyItem) *Node {
+	var result *Node
+	for node := m.root; !node.isLeaf(); {
+		switch m.compareFunc(key, node.key) {
+		case 0:
+			return node
+		case 2:
+			result = node
+			node = node.right
+		default:
+			node = node.left
+		}
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+package rbmap
+
+import "sync/atomic"
+
+// 每次写入val时给键值对分配一个新的代数，调用方读取时记下代数，写回时用SetIfGeneration检查期间没有被别人修改，
+// 不需要对整个范围加锁就能做单个key的乐观并发控制
+//
+// 代数来自所有Map共享的递增计数器，所以删除后重新添加的key、ReplaceAll换入的键值对都不会得到以前用过的代数
+
+// 全局的代数计数器
+var generations atomic.Uint64
+
+// 给节点分配新的代数，并清空缓存的子树哈希
+func (n *Node) stamp() {
+	n.gen = generations.Add(1)
+	n.invalidate()
+}
+
+// Generation 获得key当前的代数，key不存在时返回false
+func (m *Map) Generation(key keyItem) (bool, uint64) {
+	node := m.lookup(key)
+	if node == nil {
+		return false, 0
+	}
+	return true, node.gen
+}
+
+// GetWithGeneration 获得key对应的val以及当前的代数，key不存在时返回false
+func (m *Map) GetWithGeneration(key keyItem) (bool, valItem, uint64) {
+	node := m.lookup(key)
+	if node == nil {
+		return false, nil, 0
+	}
+	m.lruTouch(node)
+	return true, node.val, node.gen
+}
+
+// SetIfGeneration 当key存在并且代数仍然为gen时把val设置为val并返回true，否则返回false，
+// 返回false时调用方应该重新读取后再重试
+func (m *Map) SetIfGeneration(key keyItem, gen uint64, val valItem) bool {
+	node := m.lookup(key)
+	if node == nil || node.gen != gen || m.throttle() != nil {
+		return false
+	}
+	m.replaceVal(node, val)
+	m.lruTouch(node)
+	return true
+}
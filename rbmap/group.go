@@ -0,0 +1,25 @@
+package rbmap
+
+// GroupBy 按照keyFn的返回值对键值对分组，返回外层key为分组、val为*Map的Map，外层使用compareFunc比较，
+// 内层使用当前Map的比较方法并保持原来的顺序，对内层Map再次调用GroupBy就可以得到多层分组
+//
+// 例如先按小时再按名字对指标分组，只需要遍历一次
+func (m *Map) GroupBy(keyFn func(key, val interface{}) interface{}, compareFunc CompareFunc) *Map {
+	groups := NewMap(compareFunc)
+	for node := m.root.minimum().skipForward(); node != nil; node = node.next().skipForward() {
+		group := keyFn(node.key, node.val)
+		pair := Pair{Key: node.key, Val: node.val, Meta: node.meta}
+		if ok, pairs := groups.Get(group); ok {
+			*pairs.(*[]Pair) = append(*pairs.(*[]Pair), pair)
+		} else {
+			groups.Add(group, &[]Pair{pair})
+		}
+	}
+	// 每组的键值对都是有序的，可以直接构建内层Map
+	for node := groups.root.minimum(); node != nil; node = node.next() {
+		inner := NewMap(m.compareFunc)
+		inner.buildSorted(*node.val.(*[]Pair))
+		node.val = inner
+	}
+	return groups
+}
@@ -0,0 +1,57 @@
+package rbmap
+
+// defaultIdempotencyWindow 默认记录的最近请求ID个数
+const defaultIdempotencyWindow = 1024
+
+// 记录最近见过的请求ID及其结果，超过容量时淘汰最早的请求ID
+type requestLog struct {
+	results map[interface{}]error
+	ring    []interface{}
+	next    int
+}
+
+func newRequestLog(window int) *requestLog {
+	return &requestLog{
+		results: make(map[interface{}]error, window),
+		ring:    make([]interface{}, 0, window),
+	}
+}
+
+// 记录请求ID的结果，环满时覆盖最早的请求ID
+func (r *requestLog) record(requestID interface{}, err error) {
+	if len(r.ring) < cap(r.ring) {
+		r.ring = append(r.ring, requestID)
+	} else {
+		delete(r.results, r.ring[r.next])
+		r.ring[r.next] = requestID
+		r.next = (r.next + 1) % len(r.ring)
+	}
+	r.results[requestID] = err
+}
+
+// WithIdempotencyWindow 设置AddIdempotent记录的最近请求ID个数，默认为1024
+func WithIdempotencyWindow(window int) Option {
+	return func(m *Map) {
+		if window > 0 {
+			m.requests = newRequestLog(window)
+		}
+	}
+}
+
+// AddIdempotent 带请求ID的Add，最近见过的请求ID重复出现时不做任何修改，直接返回第一次执行的结果，
+// 用于至少一次投递的场景下安全地重试；请求ID必须可以作为go map的键
+func (m *Map) AddIdempotent(requestID interface{}, key keyItem, val valItem) error {
+	if m.requests == nil {
+		m.requests = newRequestLog(defaultIdempotencyWindow)
+	}
+	if err, ok := m.requests.results[requestID]; ok {
+		return err
+	}
+	err := m.Add(key, val)
+	if err != ErrNodeAlreadyExists && err != nil {
+		// 树损坏等错误不记录，允许重试
+		return err
+	}
+	m.requests.record(requestID, err)
+	return err
+}
@@ -0,0 +1,124 @@
+// Package index: 用rbmap实现的倒排索引，每个词对应一个有序的文档集合，交集通过跳跃查找完成，并集通过k路归并完成
+package index
+
+import (
+	"rbtree/rbmap"
+	"sort"
+)
+
+// Index 倒排索引，词到文档集合的映射，文档集合是以文档ID为key的rbmap.Map，不是并发安全的
+type Index struct {
+	terms      map[string]*rbmap.Map
+	docCompare rbmap.CompareFunc
+}
+
+// New 创建倒排索引，docCompare用于比较文档ID
+func New(docCompare rbmap.CompareFunc) *Index {
+	return &Index{terms: make(map[string]*rbmap.Map), docCompare: docCompare}
+}
+
+// AddPosting 把文档doc加入term的文档集合，已经存在时返回false
+func (x *Index) AddPosting(term string, doc interface{}) bool {
+	postings, ok := x.terms[term]
+	if !ok {
+		postings = rbmap.NewMap(x.docCompare)
+		x.terms[term] = postings
+	}
+	return postings.Add(doc, nil) == nil
+}
+
+// RemovePosting 把文档doc从term的文档集合中删除，文档集合为空时删除这个词，不存在时返回false
+func (x *Index) RemovePosting(term string, doc interface{}) bool {
+	postings, ok := x.terms[term]
+	if !ok || postings.Delete(doc) != nil {
+		return false
+	}
+	if postings.Len() == 0 {
+		delete(x.terms, term)
+	}
+	return true
+}
+
+// Postings 按照从小到大的顺序返回term的所有文档
+func (x *Index) Postings(term string) []interface{} {
+	postings, ok := x.terms[term]
+	if !ok {
+		return nil
+	}
+	docs := make([]interface{}, 0, postings.Len())
+	for doc := range postings.Keys() {
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// Terms 获得词的个数
+func (x *Index) Terms() int {
+	return len(x.terms)
+}
+
+// Intersect 按照从小到大的顺序返回包含所有terms的文档，从最小的文档集合开始依次与其他集合求交集，
+// 在较大的集合中跳跃查找，集合大小为s和l时时间复杂度接近O(s·log(l/s))而不是O(s+l)
+func (x *Index) Intersect(terms ...string) []interface{} {
+	if len(terms) == 0 {
+		return nil
+	}
+	lists := make([]*rbmap.Map, 0, len(terms))
+	for _, term := range terms {
+		postings, ok := x.terms[term]
+		if !ok {
+			return nil
+		}
+		lists = append(lists, postings)
+	}
+	sort.Slice(lists, func(i, j int) bool { return lists[i].Len() < lists[j].Len() })
+	result := make([]interface{}, 0, lists[0].Len())
+	for doc := range lists[0].Keys() {
+		result = append(result, doc)
+	}
+	for _, postings := range lists[1:] {
+		result = x.intersect(result, postings)
+		if len(result) == 0 {
+			break
+		}
+	}
+	return result
+}
+
+// 求有序的docs与postings的交集，结果直接写回docs，
+// postings的游标只在落后时Seek到下一个文档，Seek从当前位置跳跃查找，docs很小时不需要遍历整个postings
+func (x *Index) intersect(docs []interface{}, postings *rbmap.Map) []interface{} {
+	it := postings.Iterator()
+	ok := it.Next()
+	n := 0
+	for _, d := range docs {
+		if ok && x.docCompare(it.Key(), d) == 1 {
+			ok = it.Seek(d)
+		}
+		if !ok {
+			break
+		}
+		if x.docCompare(it.Key(), d) == 0 {
+			docs[n] = d
+			n++
+		}
+	}
+	return docs[:n]
+}
+
+// Union 按照从小到大的顺序返回包含任意一个terms的文档，使用k路归并并去掉重复的文档
+func (x *Index) Union(terms ...string) []interface{} {
+	lists := make([]*rbmap.Map, 0, len(terms))
+	for _, term := range terms {
+		if postings, ok := x.terms[term]; ok {
+			lists = append(lists, postings)
+		}
+	}
+	var result []interface{}
+	for doc := range rbmap.MergeIterators(lists...) {
+		if len(result) == 0 || x.docCompare(result[len(result)-1], doc) != 0 {
+			result = append(result, doc)
+		}
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+package rbmap
+
+import (
+	"sync"
+	"time"
+)
+
+// CompareMetrics 比较方法的调用统计，MaxPath为一次查找中最多连续比较了多少次
+type CompareMetrics struct {
+	Calls   uint64
+	Total   time.Duration
+	MaxPath int
+}
+
+// InstrumentedCompare 统计调用次数和耗时的比较方法，用于发现基于反射等开销很大的比较方法，
+// 把Compare传给NewMap，之后通过Metrics获得统计信息，可以被多个协程同时调用
+type InstrumentedCompare struct {
+	cmp     CompareFunc
+	mu      sync.Mutex
+	metrics CompareMetrics
+	// 上一次比较的左侧参数以及连续使用它比较的次数
+	last interface{}
+	path int
+}
+
+// InstrumentCompare 包装比较方法cmp，例如 ic := rbmap.InstrumentCompare(cmp); m := rbmap.NewMap(ic.Compare)
+//
+// 查找时树会一直用要查找的key和路径上的节点比较，所以左侧参数相同的连续比较视为同一条查找路径，
+// 是否相同用cmp判断，所以切片等不能用==比较的key也可以统计，这次额外的比较不计入统计
+func InstrumentCompare(cmp CompareFunc) *InstrumentedCompare {
+	return &InstrumentedCompare{cmp: cmp}
+}
+
+// Compare 调用被包装的比较方法并记录统计信息
+func (c *InstrumentedCompare) Compare(a, b interface{}) uint8 {
+	start := time.Now()
+	result := c.cmp(a, b)
+	elapsed := time.Since(start)
+	c.mu.Lock()
+	c.metrics.Calls++
+	c.metrics.Total += elapsed
+	if c.path > 0 && c.cmp(a, c.last) == 0 {
+		c.path++
+	} else {
+		c.last, c.path = a, 1
+	}
+	c.metrics.MaxPath = max(c.metrics.MaxPath, c.path)
+	c.mu.Unlock()
+	return result
+}
+
+// Metrics 获得目前为止的统计信息
+func (c *InstrumentedCompare) Metrics() CompareMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.metrics
+}
+
+// Reset 清空统计信息
+func (c *InstrumentedCompare) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.metrics, c.last, c.path = CompareMetrics{}, nil, 0
+}
@@ -0,0 +1,102 @@
+package rbmap
+
+// 值去重：很多key对应少数几个较大的值时，相同的值只保存一份
+
+// 去重表中的一个值以及引用它的节点个数
+type internEntry struct {
+	val  valItem
+	refs int
+}
+
+// 值去重表
+type valueInterner struct {
+	hash    func(v interface{}) uint64
+	equal   func(a, b interface{}) bool
+	buckets map[uint64][]*internEntry
+	count   int
+}
+
+// WithValueInterning 开启值去重，写入的值如果与已有的值相等（hash相同并且equal返回true）就改为保存已有的那一份，
+// equal为nil时使用WithValueEqual设置的相等方法
+func WithValueInterning(hash func(v interface{}) uint64, equal func(a, b interface{}) bool) Option {
+	return func(m *Map) {
+		m.interner = &valueInterner{
+			hash:    hash,
+			equal:   equal,
+			buckets: make(map[uint64][]*internEntry),
+		}
+	}
+}
+
+// InternedValues 获得去重表中不同值的个数，没有开启值去重时返回0
+func (m *Map) InternedValues() int {
+	if m.interner == nil {
+		return 0
+	}
+	return m.interner.count
+}
+
+// 获得val在去重表中的那一份并增加引用计数
+func (m *Map) internVal(val valItem) valItem {
+	in := m.interner
+	if in == nil {
+		return val
+	}
+	h := in.hash(val)
+	for _, entry := range in.buckets[h] {
+		if m.internEqual(entry.val, val) {
+			entry.refs++
+			return entry.val
+		}
+	}
+	in.buckets[h] = append(in.buckets[h], &internEntry{val: val, refs: 1})
+	in.count++
+	return val
+}
+
+// 减少val的引用计数，没有节点引用时从去重表中删除
+func (m *Map) releaseVal(val valItem) {
+	in := m.interner
+	if in == nil {
+		return
+	}
+	h := in.hash(val)
+	bucket := in.buckets[h]
+	for i, entry := range bucket {
+		if m.internEqual(entry.val, val) {
+			if entry.refs--; entry.refs == 0 {
+				bucket[i] = bucket[len(bucket)-1]
+				bucket = bucket[:len(bucket)-1]
+				if len(bucket) == 0 {
+					delete(in.buckets, h)
+				} else {
+					in.buckets[h] = bucket
+				}
+				in.count--
+			}
+			return
+		}
+	}
+}
+
+// 用val替换节点原来的值
+func (m *Map) replaceVal(node *Node, val valItem) {
+	if !node.isDeleted() {
+		m.unindexCounter(node.key, node.val)
+		m.indexCounter(node.key, val)
+	}
+	if m.interner != nil {
+		m.releaseVal(node.val)
+		val = m.internVal(val)
+	}
+	node.val = val
+	node.stamp()
+}
+
+// 使用去重表的相等方法判断两个值是否相等
+func (m *Map) internEqual(a, b valItem) bool {
+	if m.interner.equal != nil {
+		return m.interner.equal(a, b)
+	}
+	return m.valEqual(a, b)
+}
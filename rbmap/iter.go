@@ -0,0 +1,181 @@
+package rbmap
+
+import "iter"
+
+// 与标准库迭代器(iter.Seq/iter.Seq2)配合使用，树中的数据可以直接交给slices.Collect、maps.Insert等标准库泛型工具
+
+// All 按照从小到大的顺序返回所有键值对的迭代器，可以用于 for key, val := range m.All()，遍历期间不能修改树
+func (m *Map) All() iter.Seq2[any, any] {
+	return func(yield func(any, any) bool) {
+		for node := m.root.minimum().skipForward(); node != nil && yield(node.key, node.val); node = node.next().skipForward() {
+		}
+	}
+}
+
+// Keys 按照从小到大的顺序返回所有key的迭代器
+func (m *Map) Keys() iter.Seq[any] {
+	return func(yield func(any) bool) {
+		for node := m.root.minimum().skipForward(); node != nil && yield(node.key); node = node.next().skipForward() {
+		}
+	}
+}
+
+// Values 按照key从小到大的顺序返回所有val的迭代器
+func (m *Map) Values() iter.Seq[any] {
+	return func(yield func(any) bool) {
+		for node := m.root.minimum().skipForward(); node != nil && yield(node.val); node = node.next().skipForward() {
+		}
+	}
+}
+
+// AppendTo 按照从小到大的顺序把所有键值对追加到dst后面并返回
+func (m *Map) AppendTo(dst []Pair) []Pair {
+	for node := m.root.minimum().skipForward(); node != nil; node = node.next().skipForward() {
+		dst = append(dst, Pair{Key: node.key, Val: node.val, Meta: node.meta})
+	}
+	return dst
+}
+
+// Insert 把迭代器中的键值对全部加入Map，已经存在的key会被覆盖；
+// Add返回其他错误（例如被限流、key的类型不一致）时停止并返回这个错误，之前的键值对已经加入
+func (m *Map) Insert(seq iter.Seq2[any, any]) error {
+	for key, val := range seq {
+		if err := m.Add(key, val); err != nil && err != ErrNodeAlreadyExists {
+			return err
+		}
+	}
+	return nil
+}
+
+// Collect 根据迭代器中的键值对创建Map，例如 rbmap.Collect(maps.All(goMap), cmp)，Insert出错时返回错误
+func Collect(seq iter.Seq2[any, any], compareFunc CompareFunc, opts ...Option) (*Map, error) {
+	m := NewMap(compareFunc, opts...)
+	if err := m.Insert(seq); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Batches 按照从小到大的顺序每次返回最多size个键值对，适合本来就要分批处理数据的场景（批量写数据库、RPC调用等），
+// 每一批都是新分配的切片，可以直接保存
+func (m *Map) Batches(size int) iter.Seq[[]Pair] {
+	if size < 1 {
+		size = 1
+	}
+	return func(yield func([]Pair) bool) {
+		batch := make([]Pair, 0, size)
+		for node := m.root.minimum().skipForward(); node != nil; node = node.next().skipForward() {
+			batch = append(batch, Pair{Key: node.key, Val: node.val, Meta: node.meta})
+			if len(batch) == size {
+				if !yield(batch) {
+					return
+				}
+				batch = make([]Pair, 0, size)
+			}
+		}
+		if len(batch) > 0 {
+			yield(batch)
+		}
+	}
+}
+
+// Bounds 区间的两端是否包含在区间内
+type Bounds uint8
+
+const (
+	// Closed 	[lo, hi]，包含两端
+	Closed Bounds = iota
+	// ClosedOpen 	[lo, hi)，包含lo不包含hi
+	ClosedOpen
+	// OpenClosed 	(lo, hi]，不包含lo包含hi
+	OpenClosed
+	// Open 	(lo, hi)，不包含两端
+	Open
+)
+
+// RangeBetween 按照从小到大的顺序返回lo到hi之间（包括两端）的键值对的迭代器，
+// 从根向下查找到lo后只遍历区间内的节点，不需要遍历整棵树
+func (m *Map) RangeBetween(lo, hi keyItem) iter.Seq2[any, any] {
+	return m.RangeBetweenBounds(lo, hi, Closed)
+}
+
+// RangeBetweenBounds 与RangeBetween相同，但是由bounds决定区间是否包含lo和hi
+func (m *Map) RangeBetweenBounds(lo, hi keyItem, bounds Bounds) iter.Seq2[any, any] {
+	return func(yield func(any, any) bool) {
+		if m.checkKey(lo) != nil || m.checkKey(hi) != nil {
+			return
+		}
+		node := m.ceilingNode(lo)
+		if node != nil && (bounds == OpenClosed || bounds == Open) && m.compareFunc(node.key, lo) == 0 {
+			node = node.next()
+		}
+		// hi不包含在区间内时遇到hi就停止
+		stop := uint8(2)
+		if bounds == ClosedOpen || bounds == Open {
+			stop = 0
+		}
+		for node = node.skipForward(); node != nil; node = node.next().skipForward() {
+			if c := m.compareFunc(node.key, hi); c == 2 || c == stop || !yield(node.key, node.val) {
+				return
+			}
+		}
+	}
+}
+
+// RangeBetweenFiltered 按照从小到大的顺序返回from到to之间（包括两端）并且pred返回true的键值对的迭代器，
+// pred在遍历树时直接调用，被过滤掉的键值对不会经过迭代器，适合选择性很高的扫描，pred中不能修改树
+func (m *Map) RangeBetweenFiltered(from, to keyItem, pred func(key, val interface{}) bool) iter.Seq2[any, any] {
+	return func(yield func(any, any) bool) {
+		if m.checkKey(from) != nil || m.checkKey(to) != nil {
+			return
+		}
+		for node := m.ceilingNode(from).skipForward(); node != nil; node = node.next().skipForward() {
+			if m.compareFunc(node.key, to) == 2 {
+				return
+			}
+			if pred(node.key, node.val) && !yield(node.key, node.val) {
+				return
+			}
+		}
+	}
+}
+
+// SampleEvery 按照从小到大的顺序返回排名为0, n, 2n, ...的键值对的迭代器，用于降采样显示很大的键空间
+func (m *Map) SampleEvery(n int) iter.Seq2[any, any] {
+	if n < 1 {
+		n = 1
+	}
+	return func(yield func(any, any) bool) {
+		rank := 0
+		for node := m.root.minimum().skipForward(); node != nil; node = node.next().skipForward() {
+			if rank%n == 0 && !yield(node.key, node.val) {
+				return
+			}
+			rank++
+		}
+	}
+}
+
+// SampleCount 按照从小到大的顺序返回排名均匀分布的k个键值对的迭代器，包括最小和最大的键值对，
+// 每个键值对通过Select在O(log n)时间内找到，总的时间复杂度为O(k·log n)，键值对个数不超过k时返回全部
+func (m *Map) SampleCount(k int) iter.Seq2[any, any] {
+	return func(yield func(any, any) bool) {
+		if k < 1 {
+			return
+		}
+		if k >= m.size {
+			m.ForEach(yield)
+			return
+		}
+		for i := 0; i < k; i++ {
+			rank := 0
+			if k > 1 {
+				rank = i * (m.size - 1) / (k - 1)
+			}
+			node := m.selectNode(rank)
+			if !yield(node.key, node.val) {
+				return
+			}
+		}
+	}
+}
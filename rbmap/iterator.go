@@ -0,0 +1,143 @@
+package rbmap
+
+import "iter"
+
+// Iterator 可以双向移动的游标，可以从任意key开始遍历并随时改变方向，
+// 例如 for ok := it.Seek(key); ok; ok = it.Next() {}，游标期间不能修改树，修改后需要重新Seek
+type Iterator struct {
+	m    *Map
+	node *Node
+	// 游标的位置：-1为第一个键值对之前，0为node，1为最后一个键值对之后
+	pos int8
+}
+
+// Iterator 获得位于第一个键值对之前的游标，第一次调用Next移动到最小的键值对，调用Prev移动到最大的键值对
+func (m *Map) Iterator() *Iterator {
+	return &Iterator{m: m, pos: -1}
+}
+
+// Next 移动到下一个键值对，没有下一个时移动到最后一个键值对之后并返回false
+func (it *Iterator) Next() bool {
+	switch it.pos {
+	case -1:
+		return it.moveTo(it.m.root.minimum().skipForward(), 1)
+	case 0:
+		return it.moveTo(it.node.next().skipForward(), 1)
+	}
+	return false
+}
+
+// Prev 移动到上一个键值对，没有上一个时移动到第一个键值对之前并返回false
+func (it *Iterator) Prev() bool {
+	switch it.pos {
+	case 1:
+		return it.moveTo(it.m.root.maximum().skipBackward(), -1)
+	case 0:
+		return it.moveTo(it.node.prev().skipBackward(), -1)
+	}
+	return false
+}
+
+// Seek 移动到第一个大于等于key的键值对，没有时移动到最后一个键值对之后并返回false，
+// 游标当前的key小于key时从当前位置开始跳跃查找，时间复杂度只与跳过的键值对个数的对数有关
+func (it *Iterator) Seek(key keyItem) bool {
+	if it.m.checkKey(key) != nil {
+		return it.moveTo(nil, 1)
+	}
+	if it.pos == 0 && it.m.compareFunc(it.node.key, key) == 1 {
+		return it.moveTo(it.m.ceilingFrom(it.node, key).skipForward(), 1)
+	}
+	return it.moveTo(it.m.ceilingNode(key).skipForward(), 1)
+}
+
+// First 移动到最小的键值对，没有键值对时返回false
+func (it *Iterator) First() bool {
+	it.pos = -1
+	return it.Next()
+}
+
+// Last 移动到最大的键值对，没有键值对时返回false
+func (it *Iterator) Last() bool {
+	it.pos = 1
+	return it.Prev()
+}
+
+// Valid 判断游标是否位于某个键值对上
+func (it *Iterator) Valid() bool {
+	return it.pos == 0
+}
+
+// Key 获得游标所在的key，游标不在键值对上时返回nil
+func (it *Iterator) Key() keyItem {
+	if it.pos != 0 {
+		return nil
+	}
+	return it.node.key
+}
+
+// Value 获得游标所在的val，游标不在键值对上时返回nil
+func (it *Iterator) Value() valItem {
+	if it.pos != 0 {
+		return nil
+	}
+	return it.node.val
+}
+
+// 移动到node，node为nil时移动到end一侧的末尾
+func (it *Iterator) moveTo(node *Node, end int8) bool {
+	it.node = node
+	if node == nil {
+		it.pos = end
+		return false
+	}
+	it.pos = 0
+	return true
+}
+
+// 从游标当前的位置开始，游标位于第一个键值对之前时先移动到最小的键值对
+func (it *Iterator) start() bool {
+	if it.pos == -1 {
+		return it.Next()
+	}
+	return it.pos == 0
+}
+
+// IntersectIter 从a和b当前的位置开始（位于第一个键值对之前时从头开始），按照从小到大的顺序返回两边都有的key以及a中的val，
+// 落后的一边直接Seek到另一边的key，Seek从当前位置跳跃查找，两边大小为s和l时时间复杂度接近O(s·log(l/s))，
+// 使用a的比较方法，遍历会移动a和b
+func IntersectIter(a, b *Iterator) iter.Seq2[any, any] {
+	return func(yield func(any, any) bool) {
+		okA, okB := a.start(), b.start()
+		for okA && okB {
+			switch a.m.compareFunc(a.Key(), b.Key()) {
+			case 0:
+				if !yield(a.Key(), a.Value()) {
+					return
+				}
+				okA, okB = a.Next(), b.Next()
+			case 1:
+				okA = a.Seek(b.Key())
+			default:
+				okB = b.Seek(a.Key())
+			}
+		}
+	}
+}
+
+// ExceptIter 与IntersectIter相同，但是返回a中有而b中没有的键值对，b只在落后于a时Seek到a的key
+func ExceptIter(a, b *Iterator) iter.Seq2[any, any] {
+	return func(yield func(any, any) bool) {
+		okA, okB := a.start(), b.start()
+		for ; okA; okA = a.Next() {
+			if okB && a.m.compareFunc(b.Key(), a.Key()) == 1 {
+				okB = b.Seek(a.Key())
+			}
+			if okB && a.m.compareFunc(a.Key(), b.Key()) == 0 {
+				continue
+			}
+			if !yield(a.Key(), a.Value()) {
+				return
+			}
+		}
+	}
+}
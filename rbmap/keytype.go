@@ -0,0 +1,52 @@
+package rbmap
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrKeyType 开启WithKeyTypeCheck时传入的key类型与第一个key不同时报错，可以用errors.Is判断
+var ErrKeyType = errors.New("key type mismatch")
+
+// KeyTypeError 传入的key类型与Map记录的key类型不同，Want为第一个添加的key的类型
+type KeyTypeError struct {
+	Key       interface{}
+	Got, Want reflect.Type
+}
+
+func (e *KeyTypeError) Error() string {
+	return fmt.Sprintf("rbmap: key %v has type %v, map keys have type %v", e.Key, e.Got, e.Want)
+}
+
+// Is 使errors.Is(err, ErrKeyType)成立
+func (e *KeyTypeError) Is(target error) bool {
+	return target == ErrKeyType
+}
+
+// WithKeyTypeCheck 记录第一个添加的key的动态类型，之后写操作传入其他类型的key时返回*KeyTypeError，读操作视为不存在，
+// 在API入口处发现int和int64混用这类问题，而不是在比较方法的类型断言里panic；nil键交给nil键策略处理，
+// 记录的类型在Clear之后仍然保留
+func WithKeyTypeCheck() Option {
+	return func(m *Map) {
+		m.typeChecked = true
+	}
+}
+
+// 开启WithKeyTypeCheck时检查key的类型
+func (m *Map) checkKeyType(key keyItem) error {
+	if !m.typeChecked || m.keyType == nil || key == nil {
+		return nil
+	}
+	if got := reflect.TypeOf(key); got != m.keyType {
+		return &KeyTypeError{Key: key, Got: got, Want: m.keyType}
+	}
+	return nil
+}
+
+// 开启WithKeyTypeCheck并且还没有记录类型时记录新添加的key的类型
+func (m *Map) recordKeyType(key keyItem) {
+	if m.typeChecked && m.keyType == nil && key != nil {
+		m.keyType = reflect.TypeOf(key)
+	}
+}
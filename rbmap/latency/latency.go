@@ -0,0 +1,148 @@
+// Package latency: 用rbmap.Map记录耗时样本，借助子树大小的增强用Select在O(log m)内计算P50/P95/P99等分位数，
+// 默认使用蓄水池采样限制内存
+package latency
+
+import (
+	"math"
+	"math/rand"
+	"rbtree/rbmap"
+	"time"
+)
+
+// DefaultReservoirSize 默认最多保留的样本个数
+const DefaultReservoirSize = 10000
+
+// Option 创建Recorder时的可选配置
+type Option func(r *Recorder)
+
+// WithResolution 把耗时向下取整到resolution的整数倍后再记录，分位数的误差不超过resolution
+func WithResolution(resolution time.Duration) Option {
+	return func(r *Recorder) {
+		r.resolution = resolution
+	}
+}
+
+// WithReservoir 最多保留size个样本（默认为DefaultReservoirSize），超过时使用蓄水池采样随机替换，
+// 内存固定但分位数是估计值，seed为随机数种子；size <= 0时保留所有样本得到精确的分位数，内存随样本个数增长
+func WithReservoir(size int, seed int64) Option {
+	return func(r *Recorder) {
+		r.capacity = size
+		r.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+// 一个样本，耗时相同时按照记录的顺序区分
+type sample struct {
+	d  time.Duration
+	id uint64
+}
+
+func compareSample(a, b interface{}) uint8 {
+	x, y := a.(sample), b.(sample)
+	switch {
+	case x.d < y.d || x.d == y.d && x.id < y.id:
+		return 1
+	case x == y:
+		return 0
+	}
+	return 2
+}
+
+// Recorder 耗时记录器，保存按照耗时排序的样本，不是并发安全的
+type Recorder struct {
+	samples    *rbmap.Map
+	resolution time.Duration
+	// 下一个样本的编号
+	next uint64
+	// 蓄水池采样的容量、当前样本以及见过的样本个数
+	capacity  int
+	reservoir []sample
+	seen      int64
+	rand      *rand.Rand
+}
+
+// New 创建耗时记录器，默认最多保留DefaultReservoirSize个样本
+func New(opts ...Option) *Recorder {
+	r := &Recorder{
+		samples:  rbmap.NewMap(compareSample),
+		capacity: DefaultReservoirSize,
+		rand:     rand.New(rand.NewSource(1)),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Record 记录一次耗时，时间复杂度O(log m)，m为保留的样本个数
+func (r *Recorder) Record(d time.Duration) {
+	if r.resolution > 0 {
+		d = d.Truncate(r.resolution)
+	}
+	s := sample{d: d, id: r.next}
+	r.next++
+	if r.capacity <= 0 {
+		r.samples.Add(s, nil)
+		return
+	}
+	r.seen++
+	if len(r.reservoir) < r.capacity {
+		r.reservoir = append(r.reservoir, s)
+		r.samples.Add(s, nil)
+	} else if i := r.rand.Int63n(r.seen); i < int64(r.capacity) {
+		r.samples.Delete(r.reservoir[i])
+		r.reservoir[i] = s
+		r.samples.Add(s, nil)
+	}
+}
+
+// Since 记录从start到现在的耗时，可以用于 defer r.Since(time.Now())
+func (r *Recorder) Since(start time.Time) {
+	r.Record(time.Since(start))
+}
+
+// Count 获得参与计算分位数的样本个数，开启蓄水池采样时不超过容量
+func (r *Recorder) Count() int64 {
+	return int64(r.samples.Len())
+}
+
+// Quantile 获得q分位数（0 < q <= 1），即排名为ceil(q*Count)的耗时，没有样本时返回0，时间复杂度O(log m)
+func (r *Recorder) Quantile(q float64) time.Duration {
+	total := r.samples.Len()
+	if total == 0 {
+		return 0
+	}
+	rank := max(1, min(int(math.Ceil(q*float64(total))), total))
+	_, key, _ := r.samples.Select(rank - 1)
+	return key.(sample).d
+}
+
+// Quantiles 获得多个分位数，结果与qs的顺序一致，时间复杂度O(k log m)
+func (r *Recorder) Quantiles(qs ...float64) []time.Duration {
+	result := make([]time.Duration, len(qs))
+	for i, q := range qs {
+		result[i] = r.Quantile(q)
+	}
+	return result
+}
+
+// P50 获得中位数
+func (r *Recorder) P50() time.Duration {
+	return r.Quantile(0.5)
+}
+
+// P95 获得95分位数
+func (r *Recorder) P95() time.Duration {
+	return r.Quantile(0.95)
+}
+
+// P99 获得99分位数
+func (r *Recorder) P99() time.Duration {
+	return r.Quantile(0.99)
+}
+
+// Reset 清空所有样本
+func (r *Recorder) Reset() {
+	r.samples.Clear()
+	r.next, r.seen, r.reservoir = 0, 0, nil
+}
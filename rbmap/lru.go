@@ -0,0 +1,126 @@
+package rbmap
+
+// 访问顺序链表：在节点之间额外维护一条按照最近访问时间排序的双向链表，
+// 这样既可以按照key的顺序遍历，也可以找到最久没有被访问的键值对进行淘汰
+
+// WithAccessOrder 开启访问顺序链表，Get、Set、Add等访问操作会把键值对移动到链表头部
+func WithAccessOrder() Option {
+	return func(m *Map) {
+		m.accessOrder = true
+	}
+}
+
+// LeastRecentlyUsed 获得最久没有被访问的键值对，不会更新访问顺序，Map为空或者没有开启访问顺序时返回false
+func (m *Map) LeastRecentlyUsed() (bool, keyItem, valItem) {
+	if m.lruTail == nil {
+		return false, nil, nil
+	}
+	return true, m.lruTail.key, m.lruTail.val
+}
+
+// EvictLeastRecentlyUsed 删除并返回最久没有被访问并且没有被固定的键值对，没有可以淘汰的键值对或者没有开启访问顺序时返回false
+func (m *Map) EvictLeastRecentlyUsed() (bool, keyItem, valItem) {
+	node := m.lruTail
+	for node != nil && node.hasFlag(flagPinned) {
+		node = node.ext.newer
+	}
+	if node == nil || m.throttle() != nil {
+		return false, nil, nil
+	}
+	key, val := node.key, node.val
+	m.removeAt(node)
+	return true, key, val
+}
+
+// Pin 固定key，被固定的键值对不会被EvictLeastRecentlyUsed淘汰，可以正常删除，删除后固定标记随之清除，
+// key不存在时返回false
+func (m *Map) Pin(key keyItem) bool {
+	node := m.lookup(key)
+	if node == nil {
+		return false
+	}
+	if !node.hasFlag(flagPinned) {
+		node.setFlag(flagPinned, true)
+		m.pinned++
+	}
+	return true
+}
+
+// Unpin 取消固定key，key不存在时返回false
+func (m *Map) Unpin(key keyItem) bool {
+	node := m.lookup(key)
+	if node == nil {
+		return false
+	}
+	if node.hasFlag(flagPinned) {
+		node.setFlag(flagPinned, false)
+		m.pinned--
+	}
+	return true
+}
+
+// IsPinned 判断key是否被固定
+func (m *Map) IsPinned(key keyItem) bool {
+	node := m.lookup(key)
+	return node != nil && node.hasFlag(flagPinned)
+}
+
+// PinnedLen 获得被固定的键值对个数
+func (m *Map) PinnedLen() int {
+	return m.pinned
+}
+
+// 把节点移动到链表头部
+func (m *Map) lruTouch(node *Node) {
+	if !m.accessOrder || m.lruHead == node {
+		return
+	}
+	m.lruUnlink(node)
+	node.extra().older = m.lruHead
+	if m.lruHead != nil {
+		m.lruHead.ext.newer = node
+	}
+	m.lruHead = node
+	if m.lruTail == nil {
+		m.lruTail = node
+	}
+}
+
+// 把节点从链表中删除
+func (m *Map) lruUnlink(node *Node) {
+	if !m.accessOrder || node.ext == nil {
+		return
+	}
+	ext := node.ext
+	if ext.newer != nil {
+		ext.newer.ext.older = ext.older
+	} else if m.lruHead == node {
+		m.lruHead = ext.older
+	}
+	if ext.older != nil {
+		ext.older.ext.newer = ext.newer
+	} else if m.lruTail == node {
+		m.lruTail = ext.newer
+	}
+	ext.older, ext.newer = nil, nil
+}
+
+// 删除有两个儿子的节点时，dst原来的键值对已经从链表中删除，src的键值对被移动到dst，让dst接替src在链表中的位置
+func (m *Map) lruReplace(dst, src *Node) {
+	if !m.accessOrder || src.ext == nil {
+		return
+	}
+	ext := dst.extra()
+	ext.older, ext.newer = src.ext.older, src.ext.newer
+	if ext.newer != nil {
+		ext.newer.ext.older = dst
+	} else if m.lruHead == src {
+		m.lruHead = dst
+	}
+	if ext.older != nil {
+		ext.older.ext.newer = dst
+	} else if m.lruTail == src {
+		m.lruTail = dst
+	}
+	src.ext.older, src.ext.newer = nil, nil
+}
@@ -0,0 +1,70 @@
+package rbmap
+
+import (
+	"container/heap"
+	"iter"
+)
+
+// MergeIterators 对多个Map做k路归并，按照从小到大的顺序返回所有Map中的键值对，
+// 用于数据按照时间或者租户分成多棵树、但是需要按照整体顺序消费的场景
+//
+// 使用第一个Map的比较方法，多个Map中相同的key会按照Map传入的顺序依次返回，遍历期间不能修改这些Map
+func MergeIterators(maps ...*Map) iter.Seq2[any, any] {
+	return func(yield func(any, any) bool) {
+		if len(maps) == 0 {
+			return
+		}
+		h := &mergeHeap{compareFunc: maps[0].compareFunc}
+		for i, m := range maps {
+			if node := m.root.minimum().skipForward(); node != nil {
+				h.cursors = append(h.cursors, mergeCursor{node: node, index: i})
+			}
+		}
+		heap.Init(h)
+		for h.Len() > 0 {
+			top := &h.cursors[0]
+			if !yield(top.node.key, top.node.val) {
+				return
+			}
+			if top.node = top.node.next().skipForward(); top.node == nil {
+				heap.Pop(h)
+			} else {
+				heap.Fix(h, 0)
+			}
+		}
+	}
+}
+
+// 归并时每个Map当前的位置
+type mergeCursor struct {
+	node  *Node
+	index int
+}
+
+// 以当前key为序的小根堆，key相同时按照Map的顺序
+type mergeHeap struct {
+	cursors     []mergeCursor
+	compareFunc CompareFunc
+}
+
+func (h *mergeHeap) Len() int { return len(h.cursors) }
+
+func (h *mergeHeap) Less(i, j int) bool {
+	switch h.compareFunc(h.cursors[i].node.key, h.cursors[j].node.key) {
+	case 1:
+		return true
+	case 2:
+		return false
+	}
+	return h.cursors[i].index < h.cursors[j].index
+}
+
+func (h *mergeHeap) Swap(i, j int) { h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i] }
+
+func (h *mergeHeap) Push(x any) { h.cursors = append(h.cursors, x.(mergeCursor)) }
+
+func (h *mergeHeap) Pop() any {
+	last := h.cursors[len(h.cursors)-1]
+	h.cursors = h.cursors[:len(h.cursors)-1]
+	return last
+}
@@ -0,0 +1,182 @@
+package rbmap
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// 每个节点缓存以它为根的子树的哈希：H(1 || 左子树哈希 || 键值对哈希 || 右子树哈希)，叶子节点为全0，被软删除的节点键值对哈希为全0；
+// 写入、删除和旋转时清空节点到根路径上的缓存，RootHash只重新计算被清空的节点，修改k个键值对后重新计算的代价为O(k log n)。
+// 根哈希取决于树的形状，内容相同但是插入顺序不同的Map根哈希可能不同，只比较内容时使用KeyDigest
+
+// ErrNotMarshaler 没有设置编码方法并且key或val没有实现encoding.BinaryMarshaler时报错
+var ErrNotMarshaler = errors.New("rbmap: item does not implement encoding.BinaryMarshaler")
+
+// Encoder 把key或val编码成稳定的字节序列，相等的内容需要得到相同的字节
+type Encoder func(item interface{}) ([]byte, error)
+
+// WithMerkleEncoder 设置RootHash、ProveMembership计算哈希时对key和val使用的编码方法，
+// 默认要求key和val实现encoding.BinaryMarshaler；ReplaceAll交换的两个Map需要使用相同的编码方法
+func WithMerkleEncoder(encode Encoder) Option {
+	return func(m *Map) {
+		m.encoder = encode
+	}
+}
+
+// MembershipProof 键值对在RootHash中的存在证明
+type MembershipProof struct {
+	Key, Val interface{}
+	// Index 键值对的排名，Size 生成证明时键值对的总个数，不参与验证
+	Index, Size int
+	// Left 和 Right 键值对所在节点的左右子树的哈希
+	Left, Right [sha256.Size]byte
+	// Path 从父节点到根节点的每一层
+	Path []ProofStep
+}
+
+// ProofStep 证明路径上的一个祖先节点
+type ProofStep struct {
+	// Entry 祖先节点的键值对哈希，Sibling 另一侧子树的哈希
+	Entry, Sibling [sha256.Size]byte
+	// FromRight 下一层的节点是否为祖先节点的右儿子
+	FromRight bool
+}
+
+// RootHash 返回整棵树的Merkle根哈希，Map为空时返回全0，编码失败时返回编码方法的错误，树的深度超过限制时返回ErrTreeCorrupted
+func (m *Map) RootHash() ([sha256.Size]byte, error) {
+	return m.subtreeHash(m.root, m.depthLimit())
+}
+
+// ProveMembership 生成key的存在证明，可以交给VerifyMembership使用RootHash验证，key不存在时返回false
+func (m *Map) ProveMembership(key keyItem) (bool, MembershipProof, error) {
+	node := m.lookup(key)
+	if node == nil {
+		return false, MembershipProof{}, nil
+	}
+	if _, err := m.RootHash(); err != nil {
+		return false, MembershipProof{}, err
+	}
+	proof := MembershipProof{
+		Key:   node.key,
+		Val:   node.val,
+		Index: m.Rank(key),
+		Size:  m.size,
+		Left:  node.left.cachedHash(),
+		Right: node.right.cachedHash(),
+	}
+	for child, parent := node, node.parent; parent != nil; child, parent = parent, parent.parent {
+		entry, err := m.entryHash(parent)
+		if err != nil {
+			return false, MembershipProof{}, err
+		}
+		step := ProofStep{Entry: entry, FromRight: parent.right == child}
+		if step.FromRight {
+			step.Sibling = parent.left.cachedHash()
+		} else {
+			step.Sibling = parent.right.cachedHash()
+		}
+		proof.Path = append(proof.Path, step)
+	}
+	return true, proof, nil
+}
+
+// VerifyMembership 验证proof中的键值对是否属于根哈希为root的Map，encode需要与生成证明的Map相同，为nil时使用encoding.BinaryMarshaler
+func VerifyMembership(root [sha256.Size]byte, proof MembershipProof, encode Encoder) bool {
+	entry, err := leafHash(encode, proof.Key, proof.Val)
+	if err != nil {
+		return false
+	}
+	h := nodeHash(proof.Left, entry, proof.Right)
+	for _, step := range proof.Path {
+		if step.FromRight {
+			h = nodeHash(step.Sibling, step.Entry, h)
+		} else {
+			h = nodeHash(h, step.Entry, step.Sibling)
+		}
+	}
+	return h == root
+}
+
+// 返回以node为根的子树的哈希，只重新计算缓存被清空的节点，最多向下depth层
+func (m *Map) subtreeHash(node *Node, depth int) ([sha256.Size]byte, error) {
+	if node.isLeaf() || node.ext != nil && node.ext.hashed {
+		return node.cachedHash(), nil
+	}
+	if depth == 0 {
+		return [sha256.Size]byte{}, ErrTreeCorrupted
+	}
+	left, err := m.subtreeHash(node.left, depth-1)
+	if err != nil {
+		return left, err
+	}
+	right, err := m.subtreeHash(node.right, depth-1)
+	if err != nil {
+		return right, err
+	}
+	entry, err := m.entryHash(node)
+	if err != nil {
+		return entry, err
+	}
+	ext := node.extra()
+	ext.digest, ext.hashed = nodeHash(left, entry, right), true
+	return ext.digest, nil
+}
+
+// 节点缓存的子树哈希，叶子节点为全0，只能在RootHash之后使用
+func (n *Node) cachedHash() [sha256.Size]byte {
+	if n.isLeaf() {
+		return [sha256.Size]byte{}
+	}
+	return n.ext.digest
+}
+
+// 清空n以及n的所有祖先缓存的子树哈希，缓存为空的节点的祖先也一定为空，遇到时就可以停止
+func (n *Node) invalidate() {
+	for ; n != nil && n.ext != nil && n.ext.hashed; n = n.parent {
+		n.ext.hashed = false
+	}
+}
+
+// 节点中键值对的哈希，被软删除时为全0
+func (m *Map) entryHash(node *Node) ([sha256.Size]byte, error) {
+	if node.isDeleted() {
+		return [sha256.Size]byte{}, nil
+	}
+	return leafHash(m.encoder, node.key, node.val)
+}
+
+// 键值对的哈希以0开头，节点的哈希以1开头，避免两者混淆；key和val的编码带上长度，避免不同的内容拼接后相同
+func leafHash(encode Encoder, key, val interface{}) (sum [sha256.Size]byte, err error) {
+	if encode == nil {
+		encode = marshalBinary
+	}
+	h := sha256.New()
+	h.Write([]byte{0})
+	for _, item := range []interface{}{key, val} {
+		data, err := encode(item)
+		if err != nil {
+			return sum, err
+		}
+		h.Write(binary.AppendUvarint(nil, uint64(len(data))))
+		h.Write(data)
+	}
+	h.Sum(sum[:0])
+	return sum, nil
+}
+
+func nodeHash(left, entry, right [sha256.Size]byte) [sha256.Size]byte {
+	buf := make([]byte, 0, 1+3*sha256.Size)
+	buf = append(append(append(append(buf, 1), left[:]...), entry[:]...), right[:]...)
+	return sha256.Sum256(buf)
+}
+
+// 默认的编码方法，使用encoding.BinaryMarshaler
+func marshalBinary(item interface{}) ([]byte, error) {
+	if marshaler, ok := item.(encoding.BinaryMarshaler); ok {
+		return marshaler.MarshalBinary()
+	}
+	return nil, fmt.Errorf("%w: %T", ErrNotMarshaler, item)
+}
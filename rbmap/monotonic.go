@@ -0,0 +1,45 @@
+package rbmap
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrOutOfOrder 开启WithMonotonicKeys时添加的key不大于已有的最大key时报错，可以用errors.Is判断
+var ErrOutOfOrder = errors.New("key out of order")
+
+// OutOfOrderError 添加的key不大于已有的最大key，Max为添加时树中最大的key（可能已被软删除）
+type OutOfOrderError struct {
+	Key, Max interface{}
+}
+
+func (e *OutOfOrderError) Error() string {
+	return fmt.Sprintf("rbmap: key %v is not greater than max key %v", e.Key, e.Max)
+}
+
+// Is 使errors.Is(err, ErrOutOfOrder)成立
+func (e *OutOfOrderError) Is(target error) bool {
+	return target == ErrOutOfOrder
+}
+
+// WithMonotonicKeys 声明key只会递增，添加不大于已有最大key的新key时返回*OutOfOrderError而不是插入，
+// 用于流处理中尽早发现时钟回拨等问题；修改已有的key不受影响
+func WithMonotonicKeys() Option {
+	return func(m *Map) {
+		m.monotonic = true
+	}
+}
+
+// 开启WithMonotonicKeys时检查新添加的key是否大于已有的最大key
+func (m *Map) checkOrder(key keyItem) error {
+	if !m.monotonic {
+		return nil
+	}
+	if m.maxNode == nil {
+		m.maxNode = m.root.maximum()
+	}
+	if m.maxNode != nil && m.compareFunc(key, m.maxNode.key) != 2 {
+		return &OutOfOrderError{Key: key, Max: m.maxNode.key}
+	}
+	return nil
+}
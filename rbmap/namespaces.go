@@ -0,0 +1,171 @@
+package rbmap
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrQuotaExceeded 写入会超过租户的配额时报错
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// NamespaceQuota 每个租户的配额，为0表示不限制
+type NamespaceQuota struct {
+	MaxEntries int
+	MaxBytes   int64
+	// 计算一个键值对占用的字节数，MaxBytes不为0时必须设置
+	SizeOf func(key, val interface{}) int64
+}
+
+// NamespaceStats 所有租户的汇总信息
+type NamespaceStats struct {
+	Tenants int
+	Entries int
+	Bytes   int64
+}
+
+// Namespaces 按照租户管理多个Map，租户的Map在第一次写入时创建，所有写操作都会检查租户的配额
+type Namespaces struct {
+	compareFunc CompareFunc
+	opts        []Option
+	quota       NamespaceQuota
+	tenants     map[string]*namespace
+	bytes       int64
+}
+
+// 一个租户的Map以及占用的字节数
+type namespace struct {
+	m     *Map
+	bytes int64
+}
+
+// NewNamespaces 创建租户管理器，每个租户的Map都使用compareFunc和opts创建
+func NewNamespaces(compareFunc CompareFunc, quota NamespaceQuota, opts ...Option) *Namespaces {
+	return &Namespaces{
+		compareFunc: compareFunc,
+		opts:        opts,
+		quota:       quota,
+		tenants:     make(map[string]*namespace),
+	}
+}
+
+// Add 向租户添加键值对，如果key存在就设置val的值并且返回ErrNodeAlreadyExists，超过配额时不写入并返回ErrQuotaExceeded
+func (n *Namespaces) Add(tenant string, key keyItem, val valItem) error {
+	ns := n.tenants[tenant]
+	if ns == nil {
+		ns = &namespace{m: NewMap(n.compareFunc, n.opts...)}
+	}
+	// 先检查配额再写入，被拒绝的写入不能经过Update，否则保留原值也会被写入
+	delta := n.sizeOf(key, val)
+	node := ns.m.lookup(key)
+	existed := node != nil
+	if existed {
+		delta -= n.sizeOf(key, node.val)
+	} else if n.quota.MaxEntries > 0 && ns.m.Len() >= n.quota.MaxEntries {
+		return ErrQuotaExceeded
+	}
+	if n.quota.MaxBytes > 0 && ns.bytes+delta > n.quota.MaxBytes {
+		return ErrQuotaExceeded
+	}
+	err := ns.m.Update(key, func(interface{}, bool) (interface{}, bool) {
+		return val, true
+	})
+	if err != nil {
+		return err
+	}
+	ns.bytes += delta
+	n.bytes += delta
+	n.tenants[tenant] = ns
+	if existed {
+		return ErrNodeAlreadyExists
+	}
+	return nil
+}
+
+// Set 设置租户中已经存在的key的值，key不存在或者超过配额时返回false
+func (n *Namespaces) Set(tenant string, key keyItem, val valItem) bool {
+	if ok, _ := n.Get(tenant, key); !ok {
+		return false
+	}
+	return n.Add(tenant, key, val) == ErrNodeAlreadyExists
+}
+
+// Get 获得租户中key对应的val,如果没有返回false
+func (n *Namespaces) Get(tenant string, key keyItem) (bool, valItem) {
+	ns := n.tenants[tenant]
+	if ns == nil {
+		return false, nil
+	}
+	return ns.m.Get(key)
+}
+
+// Delete 删除租户中的key，如果不存在返回ErrNodeNotExists
+func (n *Namespaces) Delete(tenant string, key keyItem) error {
+	ns := n.tenants[tenant]
+	if ns == nil {
+		return ErrNodeNotExists
+	}
+	ok, val := ns.m.Get(key)
+	if !ok {
+		return ErrNodeNotExists
+	}
+	if err := ns.m.Delete(key); err != nil {
+		return err
+	}
+	size := n.sizeOf(key, val)
+	ns.bytes -= size
+	n.bytes -= size
+	return nil
+}
+
+// Map 获得租户的只读视图，租户不存在时返回nil
+func (n *Namespaces) Map(tenant string) *ReadOnlyMap {
+	ns := n.tenants[tenant]
+	if ns == nil {
+		return nil
+	}
+	return ns.m.ReadOnly()
+}
+
+// Drop 删除租户及其所有数据
+func (n *Namespaces) Drop(tenant string) {
+	if ns := n.tenants[tenant]; ns != nil {
+		n.bytes -= ns.bytes
+		delete(n.tenants, tenant)
+	}
+}
+
+// Tenants 按照字典序返回所有租户
+func (n *Namespaces) Tenants() []string {
+	tenants := make([]string, 0, len(n.tenants))
+	for tenant := range n.tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// Usage 获得租户的键值对个数和占用的字节数
+func (n *Namespaces) Usage(tenant string) (int, int64) {
+	ns := n.tenants[tenant]
+	if ns == nil {
+		return 0, 0
+	}
+	return ns.m.Len(), ns.bytes
+}
+
+// Stats 获得所有租户的汇总信息
+func (n *Namespaces) Stats() NamespaceStats {
+	stats := NamespaceStats{Tenants: len(n.tenants), Bytes: n.bytes}
+	for _, ns := range n.tenants {
+		stats.Entries += ns.m.Len()
+	}
+	return stats
+}
+
+// 计算键值对占用的字节数，没有设置SizeOf时为0
+func (n *Namespaces) sizeOf(key, val interface{}) int64 {
+	if n.quota.SizeOf == nil {
+		return 0
+	}
+	return n.quota.SizeOf(key, val)
+}
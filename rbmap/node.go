@@ -1,15 +1,27 @@
 package rbmap
 
-type keyItem interface{}
+type keyItem = interface{}
 
-type valItem interface{}
+type valItem = interface{}
 
 // Node 节点结构体，实现的方法都是不安全的，未进行越界判断的
 type Node struct {
-	key                 keyItem // 键值
-	val                 valItem // 价值
-	left, right, parent *Node   // 左，右指针和指向父节点的指针
-	color               bool    // 节点颜色
+	key                 keyItem  // 键值
+	val                 valItem  // 价值
+	left, right, parent *Node    // 左，右指针和指向父节点的指针
+	color               bool     // 节点颜色
+	flags               uint8    // 节点的标志位
+	meta                uint64   // 用户自定义的元数据
+	gen                 uint64   // 最后一次写入val时分配的代数
+	count               int      // 以此节点为根的子树中没有被软删除的节点个数，叶子节点为0
+	ext                 *nodeExt // 只有部分功能需要的字段，没有用到时为nil
+}
+
+// 只在开启访问顺序或者计算Merkle哈希时才需要的节点字段，单独分配，没有用到这些功能时每个节点只多一个指针
+type nodeExt struct {
+	older, newer *Node    // 访问顺序链表中更早和更晚被访问的节点
+	digest       [32]byte // 缓存的子树Merkle哈希
+	hashed       bool     // digest是否有效
 }
 
 const (
@@ -19,26 +31,95 @@ const (
 	BLACK = false
 )
 
-// 创建叶子节点
-func newLeaf() *Node {
-	return &Node{
-		left:   nil,
-		right:  nil,
-		parent: nil,
-		color:  BLACK,
+const (
+	// 脏标记
+	flagDirty uint8 = 1 << iota
+	// 软删除标记
+	flagDeleted
+	// 固定标记，固定的键值对不会被淘汰
+	flagPinned
+)
+
+// 获得叶子节点，同一个Map的所有叶子共用一个黑色的哨兵节点，不再为每个儿子单独分配；
+// 哨兵的parent只在删除后的调整中临时使用，其他时候没有意义
+func (m *Map) sentinel() *Node {
+	if m.leaf == nil {
+		m.leaf = &Node{color: BLACK}
+	}
+	return m.leaf
+}
+
+// 创建红色节点
+func (m *Map) newNode(key keyItem, val valItem) *Node {
+	node := m.allocator().New()
+	node.key, node.val = key, val
+	node.color = RED
+	return node
+}
+
+// 把src节点存放的键值对及其附加信息复制到当前节点，删除有两个儿子的节点时使用
+func (n *Node) copyEntry(src *Node) {
+	n.key, n.val = src.key, src.val
+	n.meta, n.flags, n.gen = src.meta, src.flags, src.gen
+}
+
+// 根据左右子树重新计算子树大小，并清空缓存的子树哈希
+func (n *Node) updateCount() {
+	n.count = n.left.count + n.right.count + btoi(!n.isDeleted())
+	n.invalidate()
+}
+
+// 把n以及n的所有祖先的子树大小加上delta并清空缓存的子树哈希，n可以为nil
+func (n *Node) addCount(delta int) {
+	for ; n != nil; n = n.parent {
+		n.count += delta
+		if n.ext != nil {
+			n.ext.hashed = false
+		}
 	}
 }
 
-// NewNode 创建红色节点
-func newNode(key keyItem, val valItem) *Node {
-	return &Node{
-		key:    key,
-		val:    val,
-		left:   nil,
-		right:  nil,
-		parent: nil,
-		color:  RED,
+// 获得节点的附加字段，没有时创建
+func (n *Node) extra() *nodeExt {
+	if n.ext == nil {
+		n.ext = new(nodeExt)
 	}
+	return n.ext
+}
+
+// 判断是否设置了标志位
+func (n *Node) hasFlag(flag uint8) bool {
+	return n.flags&flag != 0
+}
+
+// 设置或者清除标志位
+func (n *Node) setFlag(flag uint8, on bool) {
+	if on {
+		n.flags |= flag
+	} else {
+		n.flags &^= flag
+	}
+}
+
+// 判断是否已被软删除
+func (n *Node) isDeleted() bool {
+	return n.hasFlag(flagDeleted)
+}
+
+// 从n开始向后跳过被软删除的节点，n可以为nil
+func (n *Node) skipForward() *Node {
+	for n != nil && n.isDeleted() {
+		n = n.next()
+	}
+	return n
+}
+
+// 从n开始向前跳过被软删除的节点，n可以为nil
+func (n *Node) skipBackward() *Node {
+	for n != nil && n.isDeleted() {
+		n = n.prev()
+	}
+	return n
 }
 
 // 判断是否是黑色叶子节点
@@ -88,3 +169,47 @@ func (n *Node) getSibling() *Node {
 func (n *Node) getUncle() *Node {
 	return n.parent.getSibling()
 }
+
+// 获得以此节点为根的子树中最小的节点，子树为空时返回nil
+func (n *Node) minimum() *Node {
+	if n.isLeaf() {
+		return nil
+	}
+	for !n.left.isLeaf() {
+		n = n.left
+	}
+	return n
+}
+
+// 获得以此节点为根的子树中最大的节点，子树为空时返回nil
+func (n *Node) maximum() *Node {
+	if n.isLeaf() {
+		return nil
+	}
+	for !n.right.isLeaf() {
+		n = n.right
+	}
+	return n
+}
+
+// 获得中序遍历的后继节点，没有时返回nil
+func (n *Node) next() *Node {
+	if !n.right.isLeaf() {
+		return n.right.minimum()
+	}
+	for !n.isRoot() && n.isRight() {
+		n = n.parent
+	}
+	return n.parent
+}
+
+// 获得中序遍历的前驱节点，没有时返回nil
+func (n *Node) prev() *Node {
+	if !n.left.isLeaf() {
+		return n.left.maximum()
+	}
+	for !n.isRoot() && n.isLeft() {
+		n = n.parent
+	}
+	return n.parent
+}
@@ -0,0 +1,64 @@
+package rbmap
+
+// Option 创建Map时的可选配置，作为NewMap的可变参数传入
+type Option func(m *Map)
+
+// NilKeyPolicy nil键的处理策略
+type NilKeyPolicy uint8
+
+const (
+	// NilKeyDefault 	默认策略，nil键直接交给比较方法处理
+	NilKeyDefault NilKeyPolicy = iota
+	// NilKeyFirst 	nil键排在所有键之前
+	NilKeyFirst
+	// NilKeyLast 	nil键排在所有键之后
+	NilKeyLast
+	// NilKeyReject 	拒绝nil键，写操作返回ErrNilKey，读操作视为不存在
+	NilKeyReject
+)
+
+// WithNilKeyPolicy 设置nil键的处理策略，避免从JSON等数据中解码出的nil键在用户的比较方法里引发panic
+func WithNilKeyPolicy(policy NilKeyPolicy) Option {
+	return func(m *Map) {
+		m.nilKeyPolicy = policy
+	}
+}
+
+// 根据nil键策略包装比较方法，nil键之间视为相等
+func wrapNilCompare(policy NilKeyPolicy, cmp CompareFunc) CompareFunc {
+	var nilFirst uint8
+	switch policy {
+	case NilKeyFirst:
+		nilFirst = 1
+	case NilKeyLast:
+		nilFirst = 2
+	default:
+		return cmp
+	}
+	return func(a, b interface{}) uint8 {
+		if a == nil && b == nil {
+			return 0
+		} else if a == nil {
+			return nilFirst
+		} else if b == nil {
+			return 3 - nilFirst
+		}
+		return cmp(a, b)
+	}
+}
+
+// 检查key是否可以使用，不可以时返回对应错误
+func (m *Map) checkKey(key keyItem) error {
+	if key == nil && m.nilKeyPolicy == NilKeyReject {
+		return ErrNilKey
+	}
+	return m.checkKeyType(key)
+}
+
+// WithMaxDepth 设置查找时允许的最大深度，超过时操作返回ErrTreeCorrupted而不是无限递归导致栈溢出，
+// 不设置时使用红黑树树高的上界2*log2(n+1)
+func WithMaxDepth(depth int) Option {
+	return func(m *Map) {
+		m.maxDepth = depth
+	}
+}
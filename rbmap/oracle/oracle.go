@@ -0,0 +1,176 @@
+// Package oracle: 用有序切片实现的参考Map，用于对红黑树做差分测试
+package oracle
+
+import (
+	"fmt"
+	"rbtree/rbmap"
+	"reflect"
+	"sort"
+)
+
+// SortedMap 有序Map需要提供的方法，rbmap.Map和SliceMap都实现了这个接口
+type SortedMap interface {
+	Add(key, val interface{}) error
+	Delete(key interface{}) error
+	Set(key, val interface{}) bool
+	Get(key interface{}) (bool, interface{})
+	Len() int
+	Pairs() []rbmap.Pair
+}
+
+// SliceMap 用有序切片实现的SortedMap，实现简单但是写操作是O(n)的，只用来作为参考实现
+type SliceMap struct {
+	pairs       []rbmap.Pair
+	compareFunc rbmap.CompareFunc
+}
+
+// NewSliceMap 传入比较key值的函数作为构造方法
+func NewSliceMap(compareFunc rbmap.CompareFunc) *SliceMap {
+	return &SliceMap{compareFunc: compareFunc}
+}
+
+// Add 添加键值对，如果key存在就设置val的值并且返回rbmap.ErrNodeAlreadyExists
+func (s *SliceMap) Add(key, val interface{}) error {
+	i, found := s.search(key)
+	if found {
+		s.pairs[i].Val = val
+		return rbmap.ErrNodeAlreadyExists
+	}
+	s.pairs = append(s.pairs, rbmap.Pair{})
+	copy(s.pairs[i+1:], s.pairs[i:])
+	s.pairs[i] = rbmap.Pair{Key: key, Val: val}
+	return nil
+}
+
+// Delete 删除key，如果key不存在返回rbmap.ErrNodeNotExists
+func (s *SliceMap) Delete(key interface{}) error {
+	i, found := s.search(key)
+	if !found {
+		return rbmap.ErrNodeNotExists
+	}
+	s.pairs = append(s.pairs[:i], s.pairs[i+1:]...)
+	return nil
+}
+
+// Set 设置key的值为val, 如果key不存在就返回false
+func (s *SliceMap) Set(key, val interface{}) bool {
+	i, found := s.search(key)
+	if found {
+		s.pairs[i].Val = val
+	}
+	return found
+}
+
+// Get 获得key对应的val,如果没有返回false
+func (s *SliceMap) Get(key interface{}) (bool, interface{}) {
+	if i, found := s.search(key); found {
+		return true, s.pairs[i].Val
+	}
+	return false, nil
+}
+
+// Len 获得键值对个数
+func (s *SliceMap) Len() int {
+	return len(s.pairs)
+}
+
+// Pairs 按照从小到大的顺序导出所有键值对
+func (s *SliceMap) Pairs() []rbmap.Pair {
+	return append([]rbmap.Pair(nil), s.pairs...)
+}
+
+// 二分查找第一个不小于key的位置以及key是否存在
+func (s *SliceMap) search(key interface{}) (int, bool) {
+	i := sort.Search(len(s.pairs), func(i int) bool {
+		return s.compareFunc(s.pairs[i].Key, key) != 1
+	})
+	return i, i < len(s.pairs) && s.compareFunc(s.pairs[i].Key, key) == 0
+}
+
+// OpKind 操作类型
+type OpKind uint8
+
+const (
+	// OpAdd 	调用Add
+	OpAdd OpKind = iota
+	// OpDelete 	调用Delete
+	OpDelete
+	// OpSet 	调用Set
+	OpSet
+	// OpGet 	调用Get
+	OpGet
+)
+
+// String 返回操作类型的名字
+func (k OpKind) String() string {
+	switch k {
+	case OpAdd:
+		return "Add"
+	case OpDelete:
+		return "Delete"
+	case OpSet:
+		return "Set"
+	case OpGet:
+		return "Get"
+	}
+	return fmt.Sprintf("OpKind(%d)", uint8(k))
+}
+
+// Op 一次操作，OpDelete和OpGet不使用Val
+type Op struct {
+	Kind OpKind
+	Key  interface{}
+	Val  interface{}
+}
+
+// Apply 对m执行这次操作，返回操作的结果
+func (op Op) Apply(m SortedMap) []interface{} {
+	switch op.Kind {
+	case OpAdd:
+		return []interface{}{m.Add(op.Key, op.Val)}
+	case OpDelete:
+		return []interface{}{m.Delete(op.Key)}
+	case OpSet:
+		return []interface{}{m.Set(op.Key, op.Val)}
+	case OpGet:
+		ok, val := m.Get(op.Key)
+		return []interface{}{ok, val}
+	}
+	return nil
+}
+
+// Divergence 被测Map与参考实现第一次出现不同时的信息
+type Divergence struct {
+	Index    int // 出现不同的操作下标，为操作个数时表示最后的内容不同
+	Op       Op
+	Expected interface{}
+	Got      interface{}
+}
+
+// Error 实现error接口
+func (d *Divergence) Error() string {
+	return fmt.Sprintf("oracle: divergence at op %d (%s %v): expected %v, got %v",
+		d.Index, d.Op.Kind, d.Op.Key, d.Expected, d.Got)
+}
+
+// CrossCheck 对subject和使用compareFunc的SliceMap执行相同的操作序列，subject中已有的键值对会先复制到参考实现中，
+// 比较每次操作的结果和长度以及最后的全部内容，返回第一次出现的不同，完全一致时返回nil
+func CrossCheck(subject SortedMap, compareFunc rbmap.CompareFunc, ops []Op) error {
+	reference := NewSliceMap(compareFunc)
+	for _, pair := range subject.Pairs() {
+		reference.Add(pair.Key, pair.Val)
+	}
+	for i, op := range ops {
+		expected, got := op.Apply(reference), op.Apply(subject)
+		if !reflect.DeepEqual(expected, got) {
+			return &Divergence{Index: i, Op: op, Expected: expected, Got: got}
+		}
+		if reference.Len() != subject.Len() {
+			return &Divergence{Index: i, Op: op, Expected: reference.Len(), Got: subject.Len()}
+		}
+	}
+	if expected, got := reference.Pairs(), subject.Pairs(); !reflect.DeepEqual(expected, got) {
+		return &Divergence{Index: len(ops), Expected: expected, Got: got}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Package orderbook: 用rbmap实现的撮合引擎订单簿，买卖两边分别是价格到价位的有序Map，同一价位的订单先进先出
+package orderbook
+
+import (
+	"errors"
+	"rbtree/rbmap"
+)
+
+// ErrDuplicateOrder 订单ID已经存在时报错
+var ErrDuplicateOrder = errors.New("order id already exists")
+
+// ErrInvalidOrder 价格或数量不合法时报错
+var ErrInvalidOrder = errors.New("invalid price or quantity")
+
+// Side 买卖方向
+type Side uint8
+
+const (
+	// Bid 	买
+	Bid Side = iota
+	// Ask 	卖
+	Ask
+)
+
+// Order 挂在订单簿上的订单，价格使用最小变动单位的整数倍表示，避免浮点数误差
+type Order struct {
+	ID    string
+	Side  Side
+	Price int64
+	Qty   int64
+}
+
+// Fill 一次成交，Qty为与OrderID这个挂单成交的数量
+type Fill struct {
+	OrderID string
+	Price   int64
+	Qty     int64
+}
+
+// 一个价位上按照时间排列的订单
+type level struct {
+	orders []*Order
+	qty    int64
+}
+
+// Book 订单簿，买方按照价格从高到低排列，卖方按照价格从低到高排列，不是并发安全的
+type Book struct {
+	bids, asks *rbmap.Map
+	orders     map[string]*Order
+}
+
+// New 创建空的订单簿
+func New() *Book {
+	return &Book{
+		bids:   rbmap.NewMap(rbmap.Reverse(rbmap.OrderedCompare[int64]())),
+		asks:   rbmap.NewOrderedMap[int64](),
+		orders: make(map[string]*Order),
+	}
+}
+
+// 获得一方的价位Map，第一个价位就是最优价
+func (b *Book) side(side Side) *rbmap.Map {
+	if side == Bid {
+		return b.bids
+	}
+	return b.asks
+}
+
+// Add 挂单，加入对应价位队列的末尾
+func (b *Book) Add(id string, side Side, price, qty int64) error {
+	if price <= 0 || qty <= 0 {
+		return ErrInvalidOrder
+	}
+	if _, ok := b.orders[id]; ok {
+		return ErrDuplicateOrder
+	}
+	order := &Order{ID: id, Side: side, Price: price, Qty: qty}
+	b.side(side).Update(price, func(val interface{}, exists bool) (interface{}, bool) {
+		lv, _ := val.(*level)
+		if !exists {
+			lv = &level{}
+		}
+		lv.orders = append(lv.orders, order)
+		lv.qty += qty
+		return lv, true
+	})
+	b.orders[id] = order
+	return nil
+}
+
+// Cancel 撤单，订单不存在时返回false
+func (b *Book) Cancel(id string) bool {
+	order, ok := b.orders[id]
+	if !ok {
+		return false
+	}
+	delete(b.orders, id)
+	b.side(order.Side).Update(order.Price, func(val interface{}, exists bool) (interface{}, bool) {
+		lv := val.(*level)
+		for i, o := range lv.orders {
+			if o == order {
+				lv.orders = append(lv.orders[:i], lv.orders[i+1:]...)
+				break
+			}
+		}
+		lv.qty -= order.Qty
+		return lv, len(lv.orders) > 0
+	})
+	return true
+}
+
+// 获得一方的最优价位
+func (b *Book) best(side Side) (bool, int64, int64) {
+	var price, qty int64
+	found := false
+	b.side(side).ForEach(func(key, val interface{}) bool {
+		price, qty, found = key.(int64), val.(*level).qty, true
+		return false
+	})
+	return found, price, qty
+}
+
+// BestBid 获得最高买价以及这个价位的总数量，没有买单时返回false
+func (b *Book) BestBid() (bool, int64, int64) {
+	return b.best(Bid)
+}
+
+// BestAsk 获得最低卖价以及这个价位的总数量，没有卖单时返回false
+func (b *Book) BestAsk() (bool, int64, int64) {
+	return b.best(Ask)
+}
+
+// Match 用方向为side、数量为qty的市价单与对手方的挂单撮合，按照价格优先、时间优先的顺序成交，
+// 返回所有成交以及没有成交的剩余数量
+func (b *Book) Match(side Side, qty int64) ([]Fill, int64) {
+	book := b.asks
+	if side == Ask {
+		book = b.bids
+	}
+	var fills []Fill
+	var emptied []interface{}
+	book.ForEach(func(key, val interface{}) bool {
+		lv := val.(*level)
+		for qty > 0 && len(lv.orders) > 0 {
+			order := lv.orders[0]
+			n := min(qty, order.Qty)
+			fills = append(fills, Fill{OrderID: order.ID, Price: order.Price, Qty: n})
+			order.Qty -= n
+			lv.qty -= n
+			qty -= n
+			if order.Qty == 0 {
+				lv.orders = lv.orders[1:]
+				delete(b.orders, order.ID)
+			}
+		}
+		if len(lv.orders) == 0 {
+			emptied = append(emptied, key)
+		}
+		return qty > 0
+	})
+	// 遍历时不能修改树，所以最后再删除成交完的价位
+	for _, price := range emptied {
+		book.Delete(price)
+	}
+	return fills, qty
+}
+
+// Len 获得订单簿中挂单的个数
+func (b *Book) Len() int {
+	return len(b.orders)
+}
+
+// Levels 获得一方价位的个数
+func (b *Book) Levels(side Side) int {
+	return b.side(side).Len()
+}
@@ -0,0 +1,104 @@
+package rbmap
+
+import (
+	"sort"
+	"strings"
+)
+
+// PathView 把string类型的key看作用sep分隔的路径，例如"a/b/c"是"a/b"的子节点，
+// 这样Map可以作为一个有序的路径注册表使用；需要树中的key都是string，并且按照字节序比较
+type PathView struct {
+	m   *Map
+	sep string
+}
+
+// Paths 获得以sep为分隔符的路径视图，sep不能为空
+func (m *Map) Paths(sep string) *PathView {
+	if sep == "" {
+		panic("rbmap: path separator must not be empty")
+	}
+	return &PathView{m: m, sep: sep}
+}
+
+// Descendants 按照从小到大的顺序返回prefix下的所有键值对，不包括prefix本身，prefix为空时返回全部
+func (p *PathView) Descendants(prefix string) []Pair {
+	var result []Pair
+	p.forEachUnder(prefix, func(key string, node *Node) bool {
+		result = append(result, Pair{Key: node.key, Val: node.val, Meta: node.meta})
+		return true
+	})
+	return result
+}
+
+// DeleteSubtree 删除prefix本身以及它下面的所有键值对，返回删除的个数，
+// 开启限流时一次为所有要删除的key获取令牌，被限流时不删除任何键值对并返回ErrRateLimited
+func (p *PathView) DeleteSubtree(prefix string) (int, error) {
+	// 删除节点时会移动键值对，所以先记录下要删除的key
+	var keys []keyItem
+	if ok, _ := p.m.Get(prefix); ok && prefix != "" {
+		keys = append(keys, prefix)
+	}
+	p.forEachUnder(prefix, func(key string, node *Node) bool {
+		keys = append(keys, node.key)
+		return true
+	})
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	if err := p.m.throttleN(len(keys)); err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, key := range keys {
+		node, err := p.m.search(key)
+		if err != nil {
+			return removed, err
+		}
+		if !node.isLeaf() && !node.isDeleted() {
+			p.m.removeAt(node)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// ChildrenOf 按照从小到大的顺序返回prefix的直接子路径，只在更深层存在的子路径也会返回，
+// 例如只有"a/b/c"时，"a"的子路径是"a/b"
+func (p *PathView) ChildrenOf(prefix string) []string {
+	// 像"a!"这样的key排在"a/b"之前，同一个子路径不一定连续出现，所以先去重再排序
+	seen := make(map[string]bool)
+	var result []string
+	start := p.under(prefix)
+	p.forEachUnder(prefix, func(key string, node *Node) bool {
+		child := key
+		if i := strings.Index(key[len(start):], p.sep); i >= 0 {
+			child = key[:len(start)+i]
+		}
+		if !seen[child] {
+			seen[child] = true
+			result = append(result, child)
+		}
+		return true
+	})
+	sort.Strings(result)
+	return result
+}
+
+// 获得prefix下所有key的公共前缀
+func (p *PathView) under(prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+	return prefix + p.sep
+}
+
+// 按照从小到大的顺序对prefix下的节点调用fn，fn返回false时停止
+func (p *PathView) forEachUnder(prefix string, fn func(key string, node *Node) bool) {
+	start := p.under(prefix)
+	for node := p.m.ceilingNode(start).skipForward(); node != nil; node = node.next().skipForward() {
+		key, ok := node.key.(string)
+		if !ok || !strings.HasPrefix(key, start) || !fn(key, node) {
+			return
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package rbmap
+
+import "sync"
+
+// Pool 可以复用的Map池，适合在每个请求中临时创建有序集合做聚合的场景，
+// 同一个池中的Map使用同一个PoolAllocator，归还的Map的节点会被其他Map复用，可以被多个协程同时使用
+type Pool struct {
+	pool sync.Pool
+}
+
+// NewPool 创建Map池，池中的Map都使用compareFunc和opts创建，opts中没有设置分配器时使用池共享的PoolAllocator
+func NewPool(compareFunc CompareFunc, opts ...Option) *Pool {
+	alloc := NewPoolAllocator()
+	opts = append([]Option{WithAllocator(alloc)}, opts...)
+	return &Pool{pool: sync.Pool{New: func() any { return NewMap(compareFunc, opts...) }}}
+}
+
+// Get 获得一个空的Map
+func (p *Pool) Get() *Map {
+	return p.pool.Get().(*Map)
+}
+
+// Put 清空m并放回池中，之后不能再使用m
+func (p *Pool) Put(m *Map) {
+	m.Clear()
+	p.pool.Put(m)
+}
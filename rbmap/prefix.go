@@ -0,0 +1,100 @@
+package rbmap
+
+import (
+	"bytes"
+	"strings"
+)
+
+// PrefixView 给所有key自动加上前缀的视图，读出的key会去掉前缀，
+// 这样多个逻辑上的集合可以安全地共用一棵以string或[]byte为key的树
+type PrefixView struct {
+	m      *Map
+	prefix interface{}
+}
+
+// WithPrefix 获得key前缀为prefix的视图，prefix必须是string或[]byte，并且与树中key的类型相同，
+// 传给视图的key也必须是同样的类型
+func (m *Map) WithPrefix(prefix interface{}) *PrefixView {
+	switch prefix.(type) {
+	case string, []byte:
+	default:
+		panic("rbmap: prefix must be string or []byte")
+	}
+	return &PrefixView{m: m, prefix: prefix}
+}
+
+// Add 添加键值对，如果key存在就设置val的值并且返回错误
+func (p *PrefixView) Add(key keyItem, val valItem) error {
+	return p.m.Add(p.join(key), val)
+}
+
+// Delete 删除key，如果不存在返回错误
+func (p *PrefixView) Delete(key keyItem) error {
+	return p.m.Delete(p.join(key))
+}
+
+// Set 设置key的值为val, 如果key不存在就返回false
+func (p *PrefixView) Set(key keyItem, val valItem) bool {
+	return p.m.Set(p.join(key), val)
+}
+
+// Get 获得key对应的val,如果没有返回false
+func (p *PrefixView) Get(key keyItem) (bool, valItem) {
+	return p.m.Get(p.join(key))
+}
+
+// ForEach 按照从小到大的顺序对视图中的键值对调用fn，传给fn的key已经去掉前缀，fn返回false时停止遍历
+func (p *PrefixView) ForEach(fn func(key, val interface{}) bool) {
+	p.m.ForEachFrom(p.prefix, func(key, val interface{}) bool {
+		rest, ok := p.strip(key)
+		return ok && fn(rest, val)
+	})
+}
+
+// Len 获得视图中键值对的个数，需要遍历视图中的所有键值对
+func (p *PrefixView) Len() int {
+	n := 0
+	p.ForEach(func(key, val interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// Range 按照从小到大的顺序返回视图中的键值对，key已经去掉前缀，和Map.Range一样需要把通道读完
+func (p *PrefixView) Range() <-chan Pair {
+	ch := make(chan Pair)
+	go func() {
+		p.ForEach(func(key, val interface{}) bool {
+			ch <- Pair{Key: key, Val: val}
+			return true
+		})
+		close(ch)
+	}()
+	return ch
+}
+
+// 给key加上前缀
+func (p *PrefixView) join(key keyItem) keyItem {
+	if prefix, ok := p.prefix.(string); ok {
+		return prefix + key.(string)
+	}
+	prefix := p.prefix.([]byte)
+	return append(append(make([]byte, 0, len(prefix)+len(key.([]byte))), prefix...), key.([]byte)...)
+}
+
+// 去掉key的前缀，key没有这个前缀时返回false
+func (p *PrefixView) strip(key keyItem) (keyItem, bool) {
+	if prefix, ok := p.prefix.(string); ok {
+		s, ok := key.(string)
+		if !ok || !strings.HasPrefix(s, prefix) {
+			return nil, false
+		}
+		return s[len(prefix):], true
+	}
+	b, ok := key.([]byte)
+	if !ok || !bytes.HasPrefix(b, p.prefix.([]byte)) {
+		return nil, false
+	}
+	return b[len(p.prefix.([]byte)):], true
+}
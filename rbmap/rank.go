@@ -0,0 +1,73 @@
+package rbmap
+
+// 顺序统计：每个节点记录子树中没有被软删除的节点个数，旋转、插入和删除时一起维护，
+// 这样可以在O(log n)时间内求出key的排名以及排名为i的键值对
+
+// Rank 获得小于key的键值对个数，key可以不存在，key存在时就是key从0开始的排名
+func (m *Map) Rank(key keyItem) int {
+	if m.checkKey(key) != nil {
+		return 0
+	}
+	rank := 0
+	for node := m.root; !node.isLeaf(); {
+		switch m.compareFunc(key, node.key) {
+		case 0:
+			return rank + node.left.count
+		case 1:
+			node = node.left
+		default:
+			rank += node.left.count + btoi(!node.isDeleted())
+			node = node.right
+		}
+	}
+	return rank
+}
+
+// Select 获得从小到大排名为i（从0开始）的键值对，i超出范围时返回false
+func (m *Map) Select(i int) (bool, keyItem, valItem) {
+	return entryOf(m.selectNode(i))
+}
+
+// 寻找排名为i的节点，i超出范围时返回nil
+func (m *Map) selectNode(i int) *Node {
+	if i < 0 || i >= m.root.count {
+		return nil
+	}
+	node := m.root
+	for {
+		if i < node.left.count {
+			node = node.left
+			continue
+		}
+		i -= node.left.count
+		if !node.isDeleted() {
+			if i == 0 {
+				return node
+			}
+			i--
+		}
+		node = node.right
+	}
+}
+
+// GetByIndex 获得从小到大排名为i（从0开始）的键值对，与Get一样会更新访问顺序，i超出范围时返回false，
+// 用于分页或者按百分位取值而不用从头遍历
+func (m *Map) GetByIndex(i int) (bool, keyItem, valItem) {
+	node := m.selectNode(i)
+	if node == nil {
+		return false, nil, nil
+	}
+	m.lruTouch(node)
+	return true, node.key, node.val
+}
+
+// DeleteByIndex 删除并返回从小到大排名为i（从0开始）的键值对，i超出范围或者被限流时返回false
+func (m *Map) DeleteByIndex(i int) (bool, keyItem, valItem) {
+	node := m.selectNode(i)
+	if node == nil || m.throttle() != nil {
+		return false, nil, nil
+	}
+	key, val := node.key, node.val
+	m.removeAt(node)
+	return true, key, val
+}
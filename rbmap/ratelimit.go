@@ -0,0 +1,72 @@
+package rbmap
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRateLimited 开启限流并且不等待时，写操作超过速率限制时报错
+var ErrRateLimited = errors.New("rate limited")
+
+// 令牌桶限流器，每个写操作按照写入的键值对个数消耗令牌，令牌以rate个每秒的速度补充，最多存放burst个
+type rateLimiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	block  bool
+}
+
+// WithRateLimit 限制所有写操作的速率为每秒rate个键值对，最多允许burst个突发，
+// block为true时等待令牌补充，为false时直接返回ErrRateLimited，
+// 用来在导入数据的高峰时保护下游的消费者，不用每个调用者都自己实现限流
+//
+// 受限流的写操作：Add、Delete、Set、Replace、Update、CompareAndSwap、WithValue、SetMeta、MarkDirty、Undelete、
+// AppendMax、SetIfGeneration、DeleteByIndex、EvictLeastRecentlyUsed、EventQueue的PopNext和PopBatch，每次消耗一个令牌，
+// 以及DeleteRange、UpdateRange、DeleteWhere和PathView.DeleteSubtree，按照涉及的键值对个数一次性获取全部令牌，被限流时不修改任何键值对；
+// 基于它们实现的操作（AddPairs、Increment、Namespaces等）同样受限流
+//
+// 返回bool的写操作被限流时返回false，需要区分限流和key不存在时使用Replace；
+// 一次需要的令牌超过burst时，block为true会等待补充足够的令牌，为false时总是返回ErrRateLimited，需要分成更小的批次；
+// 不受限流的操作：Clear、ReplaceAll、Scan、Compact、Pin、Unpin、FlushDirty这类维护操作，以及FromPairs、LoadSharded等创建新Map的函数
+func WithRateLimit(rate float64, burst int, block bool) Option {
+	return func(m *Map) {
+		if rate <= 0 || burst < 1 {
+			return
+		}
+		m.limiter = &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now(), block: block}
+	}
+}
+
+// 写操作前获取一个令牌，没有开启限流时直接返回
+func (m *Map) throttle() error {
+	return m.throttleN(1)
+}
+
+// 批量写操作前获取n个令牌，不等待时n超过burst的请求永远不会成功
+func (m *Map) throttleN(n int) error {
+	l := m.limiter
+	if l == nil || n <= 0 {
+		return nil
+	}
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	need := float64(n)
+	if l.tokens >= need {
+		l.tokens -= need
+		return nil
+	}
+	if !l.block {
+		return ErrRateLimited
+	}
+	// 等待补充到需要的令牌后直接消耗掉
+	wait := time.Duration((need - l.tokens) / l.rate * float64(time.Second))
+	time.Sleep(wait)
+	l.tokens = 0
+	l.last = now.Add(wait)
+	return nil
+}
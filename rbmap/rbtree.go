@@ -2,7 +2,11 @@
 package rbmap
 
 import (
+	"context"
+	"crypto/cipher"
 	"errors"
+	"math/bits"
+	"reflect"
 )
 
 // golang 不支持重载运算符，所以只能通过方法调用
@@ -24,6 +28,10 @@ var (
 	// ErrNodeAlreadyExists 插入节点时节点早已存在时报错
 	ErrNodeAlreadyExists = errors.New("node already exists")
 	ErrNodeNotExists     = errors.New("node not exists")
+	// ErrNilKey 使用NilKeyReject策略时传入nil键报错
+	ErrNilKey = errors.New("nil key")
+	// ErrTreeCorrupted 树的结构被破坏时报错，通常是比较方法不一致导致的
+	ErrTreeCorrupted = errors.New("tree corrupted")
 )
 
 // Map 自定义的Map,提供常用接口
@@ -32,30 +40,149 @@ type Map struct {
 	size int
 	// 0：a==b, 1: a < b, 2 : a > b
 	compareFunc CompareFunc
+	// nil键的处理策略
+	nilKeyPolicy NilKeyPolicy
+	// 判断val相等的方法，为nil时使用reflect.DeepEqual
+	valueEqual ValueEqualFunc
+	// 是否开启软删除模式，以及被软删除的节点个数
+	softDelete bool
+	tombstones int
+	// 查找时允许的最大深度，为0时根据节点个数计算
+	maxDepth int
+	// 值去重表，为nil时不去重
+	interner *valueInterner
+	// 是否开启访问顺序链表，以及链表中最近和最久被访问的节点
+	accessOrder      bool
+	lruHead, lruTail *Node
+	// 被固定的键值对个数
+	pinned int
+	// AppendMax缓存的最大的节点（可能已被软删除），为nil时需要重新查找
+	maxNode *Node
+	// 是否只允许添加递增的key
+	monotonic bool
+	// AddIdempotent最近见过的请求ID
+	requests *requestLog
+	// 写操作的限流器，为nil时不限流
+	limiter *rateLimiter
+	// 节点分配器，为nil时使用DefaultAllocator
+	alloc Allocator
+	// 快照数据的压缩方法，为nil时不压缩
+	compressor Compressor
+	// 快照数据的加密方法，为nil时不加密
+	aead cipher.AEAD
+	// 长时间运行的操作每处理多少个节点让出一次处理器，为0时不主动让出
+	yieldEvery int
+	// 是否检查key的类型，以及第一个添加的key的类型
+	typeChecked bool
+	keyType     reflect.Type
+	// Map启动的后台组件，Shutdown时统一停止
+	background []*BackgroundValidation
+	// 按照int64计数排序的索引，为nil时不维护
+	counters *Map
+	// 计算Merkle哈希时key和val的编码方法，为nil时使用encoding.BinaryMarshaler
+	encoder Encoder
+	// 所有叶子共用的哨兵节点
+	leaf *Node
 }
 
-// NewMap 传入比较key值的函数作为构造方法
-func NewMap(compareFunc CompareFunc) *Map {
-	return &Map{
-		root:        newLeaf(),
+// NewMap 传入比较key值的函数作为构造方法，opts为可选配置
+func NewMap(compareFunc CompareFunc, opts ...Option) *Map {
+	m := &Map{
 		size:        0,
 		compareFunc: compareFunc,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.root = m.sentinel()
+	m.compareFunc = wrapNilCompare(m.nilKeyPolicy, m.compareFunc)
+	return m
 }
 
-// Pair 键值对结构体
-type Pair struct {
-	Key keyItem
-	Val valItem
+// Pair Map使用的键值对结构体
+type Pair = GenericPair[any, any]
+
+// GenericPair 泛型的键值对结构体，TreeMap使用GenericPair[K, V]
+type GenericPair[K, V any] struct {
+	Key  K
+	Val  V
+	Meta uint64 // 节点的元数据，见SetMeta
+}
+
+// Compare 使用cmp比较两个键值对的key
+func (p GenericPair[K, V]) Compare(other GenericPair[K, V], cmp CompareFunc) uint8 {
+	return cmp(p.Key, other.Key)
 }
 
 // public:
 
-// Range 根据中序遍历的方式循环红黑树，返回对应键值对
+// Range 根据中序遍历的方式循环红黑树，返回对应键值对，树的深度超过限制（树已经损坏）时提前关闭通道
 func (m *Map) Range() <-chan Pair {
 	ch := make(chan Pair)
+	depth := m.depthLimit()
+	go func() {
+		m.ran(m.root, ch, depth)
+		close(ch)
+	}()
+	return ch
+}
+
+// RangeBuffered 与Range相同，但是使用容量为n的带缓冲通道，生产者最多可以领先消费者n个键值对，
+// 避免慢消费者在每次交接元素时都阻塞生产者
+//
+// 生产者协程在遍历完成后关闭通道并退出；如果消费者提前停止读取，生产者会一直阻塞在通道上无法退出，
+// 所以必须把通道读完，并且遍历期间不能修改树
+func (m *Map) RangeBuffered(n int) <-chan Pair {
+	if n < 0 {
+		n = 0
+	}
+	ch := make(chan Pair, n)
+	depth := m.depthLimit()
+	go func() {
+		m.ran(m.root, ch, depth)
+		close(ch)
+	}()
+	return ch
+}
+
+// RangeCtx 与Range相同，但是ctx取消后生产者协程会关闭通道并退出，消费者提前停止读取时调用cancel即可，
+// 不需要把通道读完，取消后最多还能读到一个键值对，遍历期间不能修改树
+func (m *Map) RangeCtx(ctx context.Context) <-chan Pair {
+	ch := make(chan Pair)
+	go func() {
+		defer close(ch)
+		for node := m.root.minimum().skipForward(); node != nil; node = node.next().skipForward() {
+			// 先检查一次，避免ctx已经取消时select随机选中发送的分支
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case ch <- Pair{Key: node.key, Val: node.val, Meta: node.meta}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// RangeKeysOnly 按照中序遍历的方式只返回key，不需要构造Pair，和Range一样需要把通道读完
+func (m *Map) RangeKeysOnly() <-chan keyItem {
+	ch := make(chan keyItem)
+	depth := m.depthLimit()
+	go func() {
+		m.ranKeys(m.root, ch, depth)
+		close(ch)
+	}()
+	return ch
+}
+
+// RangeValuesOnly 按照中序遍历的方式只返回val，不需要构造Pair，和Range一样需要把通道读完
+func (m *Map) RangeValuesOnly() <-chan valItem {
+	ch := make(chan valItem)
+	depth := m.depthLimit()
 	go func() {
-		m.ran(m.root, ch)
+		m.ranValues(m.root, ch, depth)
 		close(ch)
 	}()
 	return ch
@@ -68,91 +195,291 @@ func (m *Map) Len() int {
 
 // Add 添加节点 key, val 如果节点存在就设置val的值并且返回错误
 func (m *Map) Add(key keyItem, val valItem) error {
-	node := m.findNode(m.root, key)
-	if node.isLeaf() {
-		m.insertNode(node, key, val)
-		m.size++
+	if err := m.checkKey(key); err != nil {
+		return err
+	}
+	if err := m.throttle(); err != nil {
+		return err
+	}
+	node, at, err := m.locate(key)
+	if err != nil {
+		return err
+	}
+	if node.isLeaf() || node.isDeleted() {
+		if err := m.checkOrder(key); err != nil {
+			return err
+		}
+		m.addAt(node, at, key, val)
 		return nil
 	}
-	node.val = val
+	m.replaceVal(node, val)
+	m.lruTouch(node)
 	return ErrNodeAlreadyExists
 }
 
 // Delete 根据key值删除对应节点， 如果节点不存在返回错误
 func (m *Map) Delete(key keyItem) error {
-	node := m.findNode(m.root, key)
-	if node.isLeaf() {
+	if err := m.checkKey(key); err != nil {
+		return err
+	}
+	if err := m.throttle(); err != nil {
+		return err
+	}
+	node, err := m.search(key)
+	if err != nil {
+		return err
+	}
+	if node.isLeaf() || node.isDeleted() {
 		return ErrNodeNotExists
 	}
-	m.eraseNode(node)
+	m.removeAt(node)
 	return nil
 }
 
-// Set 设置节点 key的值为val, 如果节点key不存在就返回false, 存在就修改返回true
+// Set 设置节点 key的值为val, 如果节点key不存在（或者被限流）就返回false, 存在就修改返回true
 func (m *Map) Set(key keyItem, val valItem) bool {
-	node := m.findNode(m.root, key)
-	if node.isLeaf() {
-		return false
+	return m.Replace(key, val) == nil
+}
+
+// Replace 和Set相同，但是返回失败的原因：key不存在时返回ErrNodeNotExists，被限流时返回ErrRateLimited
+func (m *Map) Replace(key keyItem, val valItem) error {
+	if err := m.checkKey(key); err != nil {
+		return err
 	}
-	node.val = val
-	return true
+	node := m.lookup(key)
+	if node == nil {
+		return ErrNodeNotExists
+	}
+	if err := m.throttle(); err != nil {
+		return err
+	}
+	m.replaceVal(node, val)
+	m.lruTouch(node)
+	return nil
 }
 
 // Get 通过键值key找到对应的val,如果没有返回false
 func (m *Map) Get(key keyItem) (bool, valItem) {
-	node := m.findNode(m.root, key)
-	if node.isLeaf() {
+	node := m.lookup(key)
+	if node == nil {
 		return false, nil
 	}
+	m.lruTouch(node)
 	return true, node.val
 }
 
-// 输出树结构，测试用
-//func (m *Map) Print() {
-//	m.print(m.root)
-//}
+// WithValue 在fn执行期间提供key对应的值所在位置的指针，可以直接原地修改值而不用Get后再Set，
+// key不存在时返回false；指针只在fn执行期间有效，不能保存下来，fn中也不能修改树
+func (m *Map) WithValue(key keyItem, fn func(val *interface{})) bool {
+	node := m.lookup(key)
+	if node == nil || m.throttle() != nil {
+		return false
+	}
+	m.lruTouch(node)
+	if m.interner != nil || m.counters != nil {
+		// 开启值去重时值可能被多个节点共享，开启计数索引时需要知道原来的值，修改副本后再写入
+		val := node.val
+		fn(&val)
+		m.replaceVal(node, val)
+		return true
+	}
+	fn(&node.val)
+	node.stamp()
+	return true
+}
+
+// Update 只查找一次完成"读-改-写"：fn的参数为key当前的值以及key是否存在，
+// fn返回新的值以及是否保留，保留时写入新的值（key不存在时添加），不保留时删除key
+//
+// Map本身没有加锁，在持有保护Map的锁时调用Update就可以保证整个"读-改-写"是原子的
+func (m *Map) Update(key keyItem, fn func(val interface{}, exists bool) (interface{}, bool)) error {
+	if err := m.checkKey(key); err != nil {
+		return err
+	}
+	if err := m.throttle(); err != nil {
+		return err
+	}
+	node, at, err := m.locate(key)
+	if err != nil {
+		return err
+	}
+	exists := !node.isLeaf() && !node.isDeleted()
+	var old valItem
+	if exists {
+		old = node.val
+	}
+	val, keep := fn(old, exists)
+	if keep && exists {
+		m.replaceVal(node, val)
+		m.lruTouch(node)
+	} else if keep {
+		if err := m.checkOrder(key); err != nil {
+			return err
+		}
+		m.addAt(node, at, key, val)
+	} else if exists {
+		m.removeAt(node)
+	}
+	return nil
+}
+
+// SetMeta 设置节点key的元数据，可以用来给键值对打标记（脏位、标志位等）而不用把val包装成结构体，
+// 如果节点key不存在就返回false
+func (m *Map) SetMeta(key keyItem, meta uint64) bool {
+	node := m.lookup(key)
+	if node == nil || m.throttle() != nil {
+		return false
+	}
+	node.meta = meta
+	return true
+}
+
+// GetMeta 获得节点key的元数据，如果没有返回false
+func (m *Map) GetMeta(key keyItem) (bool, uint64) {
+	node := m.lookup(key)
+	if node == nil {
+		return false, 0
+	}
+	return true, node.meta
+}
 
 // private:
 
-//func (m *Map) print(node *Node) {
-//	if !node.isLeaf() {
-//		m.print(node.left)
-//		fmt.Print("key: ", node.key, " color: ", node.color, " isLeft?: ", !node.isRoot() && node.isLeft(), " parent: ")
-//		if !node.isRoot() {
-//			fmt.Println(node.parent.key)
-//		} else {
-//			fmt.Println("None")
-//		}
-//		m.print(node.right)
-//	}
-//}
-
-// 寻找节点，因为红黑树树高不会太高，所以选择递归寻找
-func (m *Map) findNode(node *Node, key keyItem) *Node {
+// 寻找key对应的存放值的节点，key不可用、不存在或者已被软删除时返回nil
+func (m *Map) lookup(key keyItem) *Node {
+	if m.checkKey(key) != nil {
+		return nil
+	}
+	node, err := m.search(key)
+	if err != nil || node.isLeaf() || node.isDeleted() {
+		return nil
+	}
+	return node
+}
+
+// 从根节点开始寻找key对应的节点，不存在时返回应该插入的叶子节点，查找深度超过限制时返回ErrTreeCorrupted
+func (m *Map) search(key keyItem) (*Node, error) {
+	node := m.findNode(m.root, key, m.depthLimit())
+	if node == nil {
+		return nil, ErrTreeCorrupted
+	}
+	return node, nil
+}
+
+// 新节点的插入位置：父节点（为nil时作为根节点）以及是否为左儿子
+type slot struct {
+	parent *Node
+	left   bool
+}
+
+// 与search相同，同时返回key不存在时新节点的插入位置，叶子节点是共用的，不能通过叶子找到父节点
+func (m *Map) locate(key keyItem) (*Node, slot, error) {
+	var at slot
+	node, depth := m.root, m.depthLimit()
+	for !node.isLeaf() {
+		if depth == 0 {
+			return nil, at, ErrTreeCorrupted
+		}
+		depth--
+		switch m.compareFunc(key, node.key) {
+		case 0:
+			return node, at, nil
+		case 1:
+			at = slot{parent: node, left: true}
+			node = node.left
+		default:
+			at = slot{parent: node}
+			node = node.right
+		}
+	}
+	return node, at, nil
+}
+
+// 寻找节点，因为红黑树树高不会太高，所以选择递归寻找，depth为还可以向下查找的层数，用完时返回nil
+func (m *Map) findNode(node *Node, key keyItem, depth int) *Node {
 	if node.isLeaf() {
 		return node
 	}
+	if depth == 0 {
+		return nil
+	}
 	c := m.compareFunc(key, node.key)
 	if c == 1 {
-		return m.findNode(node.left, key)
+		return m.findNode(node.left, key, depth-1)
 	} else if c == 2 {
-		return m.findNode(node.right, key)
+		return m.findNode(node.right, key, depth-1)
 	} else {
 		return node
 	}
 }
 
-// 插入节点，并且设置key和val
-func (m *Map) insertNode(node *Node, key keyItem, val valItem) {
-	node.key = key
-	node.val = val
-	node.color = RED
-	node.left = newLeaf()
-	node.right = newLeaf()
-	node.left.parent = node
-	node.right.parent = node
+// 在查找到的叶子节点（插入到at）或者被软删除的节点上添加键值对
+func (m *Map) addAt(node *Node, at slot, key keyItem, val valItem) {
+	if node.isDeleted() {
+		// 软删除的节点直接复用
+		m.revive(node)
+		m.replaceVal(node, val)
+		node.meta, node.flags = 0, 0
+		m.lruTouch(node)
+		return
+	}
+	node = m.insertNode(at, key, m.internVal(val))
+	if m.maxNode != nil && at.parent == m.maxNode && !at.left {
+		// 插入到最大的节点的右边时，新的节点成为最大的节点
+		m.maxNode = node
+	}
+	node.stamp()
+	m.indexCounter(key, node.val)
+	m.recordKeyType(key)
+	m.lruTouch(node)
+	m.size++
+}
+
+// 删除存放值的节点
+func (m *Map) removeAt(node *Node) {
+	m.unindexCounter(node.key, node.val)
+	m.lruUnlink(node)
+	if node.hasFlag(flagPinned) {
+		node.setFlag(flagPinned, false)
+		m.pinned--
+	}
+	if m.softDelete {
+		// 软删除模式下只做标记，不需要调整树
+		node.setFlag(flagDeleted, true)
+		node.addCount(-1)
+		m.size--
+		m.tombstones++
+		return
+	}
+	m.releaseVal(node.val)
+	m.eraseNode(node)
+	m.size--
+}
+
+// 获得查找时允许的最大深度，未设置时使用红黑树树高的上界2*log2(n+1)，超过它说明树的结构已经被破坏
+func (m *Map) depthLimit() int {
+	if m.maxDepth > 0 {
+		return m.maxDepth
+	}
+	return 2 * bits.Len(uint(m.size+m.tombstones+1))
+}
+
+// 在at插入存放key和val的新节点，进行调整后返回新节点
+func (m *Map) insertNode(at slot, key keyItem, val valItem) *Node {
+	node := m.newNode(key, val)
+	node.left, node.right, node.parent = m.sentinel(), m.sentinel(), at.parent
+	node.count = 1
+	if at.parent != nil {
+		if at.left {
+			at.parent.left = node
+		} else {
+			at.parent.right = node
+		}
+	}
+	at.parent.addCount(1)
 	// 进行插入调整
 	m.insertSort(node)
+	return node
 }
 
 // 对插入节点进行调整
@@ -199,6 +526,13 @@ func (m *Map) insertSort(node *Node) {
 
 // 删除节点node
 func (m *Map) eraseNode(node *Node) {
+	if node == m.maxNode {
+		m.maxNode = nil
+	}
+	if node.left.isLeaf() || node.right.isLeaf() {
+		// 节点离开树后祖先的子树中不再包含它
+		node.parent.addCount(-btoi(!node.isDeleted()))
+	}
 	if node.left.isLeaf() {
 		// 如果节点没有子节点或者只有右子节点，就用右子节点代替当前节点
 		rightChild := node.right
@@ -211,11 +545,11 @@ func (m *Map) eraseNode(node *Node) {
 				parent.right = rightChild
 			}
 		}
-		// 只有删除黑色节点才需要调整
+		// 只有删除黑色节点才需要调整，rightChild是叶子时借用哨兵的parent记录位置
 		if node.isBlack() {
 			m.eraseSort(rightChild)
 		}
-		node = nil
+		m.freeNode(node)
 	} else if node.right.isLeaf() {
 		// 如果左节点非空并且右节点是空节点
 		leftChild := node.left
@@ -232,11 +566,15 @@ func (m *Map) eraseNode(node *Node) {
 		if node.isBlack() {
 			m.eraseSort(leftChild)
 		}
-		node = nil
+		m.freeNode(node)
 	} else {
 		// 如果节点有左右子节点，就找前继节点进行替换再删除前继节点
 		leftMostChild := m.getLeftMostChild(node)
-		node.key, node.val = leftMostChild.key, leftMostChild.val
+		// 前继节点的键值对移动到当前节点，当前节点是否被软删除可能改变
+		delta := btoi(!leftMostChild.isDeleted()) - btoi(!node.isDeleted())
+		node.copyEntry(leftMostChild)
+		node.addCount(delta)
+		m.lruReplace(node, leftMostChild)
 		m.eraseNode(leftMostChild)
 	}
 }
@@ -244,10 +582,10 @@ func (m *Map) eraseNode(node *Node) {
 // 寻找对应node节点的前继节点
 func (m *Map) getLeftMostChild(node *Node) *Node {
 	leftChild := node.left
-	for !leftChild.isLeaf() {
+	for !leftChild.right.isLeaf() {
 		leftChild = leftChild.right
 	}
-	return leftChild.parent
+	return leftChild
 }
 
 // 删除节点后的调整
@@ -335,8 +673,14 @@ func (m *Map) rotateLeft(node *Node) {
 	rightChild.parent, node.parent = parent, rightChild
 	// 更换对应子节点信息将当前节点的右儿子替换成右儿子的左儿子, 为了避免出错, 先更改对应的父节点指向再更改当前右儿子的孩子节点信息
 	node.right = rightChild.left
-	node.right.parent = node
+	if !node.right.isLeaf() {
+		// 哨兵的parent可能正在被删除调整使用，不能修改
+		node.right.parent = node
+	}
 	rightChild.left = node
+	// 旋转不改变整棵子树的大小
+	rightChild.count = node.count
+	node.updateCount()
 }
 
 // 在树中对节点进行左旋，右旋时注意：右旋节点一定要有左儿子
@@ -368,18 +712,57 @@ func (m *Map) rotateRight(node *Node) {
 	leftChild.parent, node.parent = parent, leftChild
 	// 再依次关系当前节点的的左儿子指向，左儿子反指回，前左儿子的右儿子指向
 	node.left = leftChild.right
-	node.left.parent = node
+	if !node.left.isLeaf() {
+		node.left.parent = node
+	}
 	leftChild.right = node
+	// 旋转不改变整棵子树的大小
+	leftChild.count = node.count
+	node.updateCount()
 }
 
-// 使用chan遍历map
-func (m *Map) ran(node *Node, ch chan<- Pair) {
-	if !node.isLeaf() {
-		m.ran(node.left, ch)
+// 使用chan遍历map，最多向下depth层，深度超过限制时说明树已经损坏，停止遍历并返回false
+func (m *Map) ran(node *Node, ch chan<- Pair, depth int) bool {
+	if node.isLeaf() {
+		return true
+	}
+	if depth == 0 || !m.ran(node.left, ch, depth-1) {
+		return false
+	}
+	if !node.isDeleted() {
 		ch <- Pair{
-			Key: node.key,
-			Val: node.val,
+			Key:  node.key,
+			Val:  node.val,
+			Meta: node.meta,
 		}
-		m.ran(node.right, ch)
 	}
+	return m.ran(node.right, ch, depth-1)
+}
+
+// 使用chan遍历map的key，深度超过限制时停止遍历并返回false
+func (m *Map) ranKeys(node *Node, ch chan<- keyItem, depth int) bool {
+	if node.isLeaf() {
+		return true
+	}
+	if depth == 0 || !m.ranKeys(node.left, ch, depth-1) {
+		return false
+	}
+	if !node.isDeleted() {
+		ch <- node.key
+	}
+	return m.ranKeys(node.right, ch, depth-1)
+}
+
+// 使用chan遍历map的val，深度超过限制时停止遍历并返回false
+func (m *Map) ranValues(node *Node, ch chan<- valItem, depth int) bool {
+	if node.isLeaf() {
+		return true
+	}
+	if depth == 0 || !m.ranValues(node.left, ch, depth-1) {
+		return false
+	}
+	if !node.isDeleted() {
+		ch <- node.val
+	}
+	return m.ranValues(node.right, ch, depth-1)
 }
@@ -0,0 +1,57 @@
+package rbmap
+
+// ReadOnlyMap Map的只读视图，只提供不会修改树的方法，
+// 可以把它交给调用方，在编译期保证调用方无法修改树
+type ReadOnlyMap struct {
+	m *Map
+}
+
+// ReadOnly 获得当前Map的只读视图
+func (m *Map) ReadOnly() *ReadOnlyMap {
+	return &ReadOnlyMap{m: m}
+}
+
+// Len 获得树的节点个数
+func (r *ReadOnlyMap) Len() int {
+	return r.m.Len()
+}
+
+// Get 通过键值key找到对应的val,如果没有返回false
+func (r *ReadOnlyMap) Get(key keyItem) (bool, valItem) {
+	return r.m.Get(key)
+}
+
+// GetMeta 获得节点key的元数据，如果没有返回false
+func (r *ReadOnlyMap) GetMeta(key keyItem) (bool, uint64) {
+	return r.m.GetMeta(key)
+}
+
+// IsDirty 判断节点key是否被标记为脏
+func (r *ReadOnlyMap) IsDirty(key keyItem) bool {
+	return r.m.IsDirty(key)
+}
+
+// Range 根据中序遍历的方式循环红黑树，返回对应键值对
+func (r *ReadOnlyMap) Range() <-chan Pair {
+	return r.m.Range()
+}
+
+// RangeBuffered 与Range相同，但是使用容量为n的带缓冲通道
+func (r *ReadOnlyMap) RangeBuffered(n int) <-chan Pair {
+	return r.m.RangeBuffered(n)
+}
+
+// RangeKeysOnly 按照中序遍历的方式只返回key
+func (r *ReadOnlyMap) RangeKeysOnly() <-chan keyItem {
+	return r.m.RangeKeysOnly()
+}
+
+// RangeValuesOnly 按照中序遍历的方式只返回val
+func (r *ReadOnlyMap) RangeValuesOnly() <-chan valItem {
+	return r.m.RangeValuesOnly()
+}
+
+// RangeDirty 按照中序遍历的方式返回所有被标记为脏的键值对
+func (r *ReadOnlyMap) RangeDirty() <-chan Pair {
+	return r.m.RangeDirty()
+}
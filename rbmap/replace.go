@@ -0,0 +1,75 @@
+package rbmap
+
+// ReplaceAll 用newContents的内容替换Map的全部内容，newContents会被清空，
+// 这样全量重建索引时可以在另一个Map中慢慢构建，构建完成后在持有保护Map的锁时调用ReplaceAll切换，
+// 读者不会看到构建了一半的树；Map保留自己的配置，newContents需要使用相同的比较方法，
+// 两者的值去重和访问顺序配置相同（都没有开启值去重）时只交换根节点，时间复杂度为O(1)，否则需要O(n)转换，
+// 开启计数索引时需要O(n log n)重建索引
+func (m *Map) ReplaceAll(newContents *Map) {
+	n := newContents
+	if n == m {
+		return
+	}
+	m.root, m.size, m.tombstones, m.pinned = n.root, n.size, n.tombstones, n.pinned
+	m.lruHead, m.lruTail, m.maxNode = nil, nil, nil
+	if m.accessOrder && n.accessOrder {
+		m.lruHead, m.lruTail = n.lruHead, n.lruTail
+	}
+	if m.interner != nil {
+		m.interner.buckets = make(map[uint64][]*internEntry)
+		m.interner.count = 0
+	}
+	if m.interner != nil || m.accessOrder != n.accessOrder {
+		for node := m.root.minimum(); node != nil; node = node.next() {
+			node.val = m.internVal(node.val)
+			if m.accessOrder != n.accessOrder {
+				// 没有访问顺序时按照key的顺序建立链表
+				if node.ext != nil {
+					node.ext.older, node.ext.newer = nil, nil
+				}
+				if m.accessOrder && !node.isDeleted() {
+					m.lruTouch(node)
+				}
+			}
+		}
+	}
+	if m.counters != nil {
+		m.resetCounters()
+		for node := m.root.minimum().skipForward(); node != nil; node = node.next().skipForward() {
+			m.indexCounter(node.key, node.val)
+		}
+	}
+	// 叶子是每个Map共用的哨兵节点，跟着树一起交换
+	m.leaf, n.leaf = n.leaf, nil
+	n.root, n.size, n.tombstones, n.pinned = n.sentinel(), 0, 0, 0
+	n.lruHead, n.lruTail, n.maxNode = nil, nil, nil
+	n.resetCounters()
+	if n.interner != nil {
+		n.interner.buckets = make(map[uint64][]*internEntry)
+		n.interner.count = 0
+	}
+}
+
+// Clear 删除所有键值对，节点会逐个归还给分配器，配置保持不变；
+// 树的深度超过限制（树已经损坏）时超出的部分不归还给分配器，交给垃圾回收
+func (m *Map) Clear() {
+	if m.alloc != nil {
+		var free func(node *Node, depth int)
+		free = func(node *Node, depth int) {
+			if node.isLeaf() || depth == 0 {
+				return
+			}
+			free(node.left, depth-1)
+			free(node.right, depth-1)
+			m.alloc.Free(node)
+		}
+		free(m.root, m.depthLimit())
+	}
+	m.root, m.size, m.tombstones, m.pinned = m.sentinel(), 0, 0, 0
+	m.lruHead, m.lruTail, m.maxNode = nil, nil, nil
+	m.resetCounters()
+	if m.interner != nil {
+		m.interner.buckets = make(map[uint64][]*internEntry)
+		m.interner.count = 0
+	}
+}
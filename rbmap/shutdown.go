@@ -0,0 +1,41 @@
+package rbmap
+
+import "context"
+
+// Map启动的后台组件（目前只有StartBackgroundValidation），嵌入Map的服务退出时调用Close或者Shutdown统一停止，
+// 不需要自己保存每个后台组件的句柄
+
+// Close 停止Map启动的所有后台组件并等待它们退出
+func (m *Map) Close() {
+	m.Shutdown(context.Background())
+}
+
+// Shutdown 通知Map启动的所有后台组件停止，并等待它们退出或者ctx结束，ctx先结束时返回ctx.Err()，
+// 此时后台组件仍然会在处理完当前这一批后退出；不能与启动后台组件的方法同时调用
+func (m *Map) Shutdown(ctx context.Context) error {
+	for _, v := range m.background {
+		v.signal()
+	}
+	for _, v := range m.background {
+		select {
+		case <-v.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	m.background = nil
+	return nil
+}
+
+// 记录新启动的后台组件，顺便去掉已经退出的组件
+func (m *Map) track(v *BackgroundValidation) {
+	running := m.background[:0]
+	for _, old := range m.background {
+		select {
+		case <-old.done:
+		default:
+			running = append(running, old)
+		}
+	}
+	m.background = append(running, v)
+}
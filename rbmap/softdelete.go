@@ -0,0 +1,75 @@
+package rbmap
+
+import "context"
+
+// 软删除模式：Delete只把节点标记为已删除，读操作和遍历都会跳过这些节点，
+// 由Compact统一从树中真正删除，这样Delete不需要调整树，并且可以通过Undelete恢复
+
+// WithSoftDelete 开启软删除模式
+func WithSoftDelete() Option {
+	return func(m *Map) {
+		m.softDelete = true
+	}
+}
+
+// Undelete 恢复被软删除并且还没有被Compact清理的节点key，恢复成功返回true
+func (m *Map) Undelete(key keyItem) bool {
+	if m.checkKey(key) != nil {
+		return false
+	}
+	node, err := m.search(key)
+	if err != nil || node.isLeaf() || !node.isDeleted() || m.throttle() != nil {
+		return false
+	}
+	m.revive(node)
+	return true
+}
+
+// Tombstones 获得被软删除但还没有被清理的节点个数
+func (m *Map) Tombstones() int {
+	return m.tombstones
+}
+
+// Compact 从树中真正删除所有被软删除的节点，返回删除的个数
+func (m *Map) Compact() int {
+	removed, _ := m.CompactCtx(context.Background())
+	return removed
+}
+
+// CompactCtx 与Compact相同，但是定期检查ctx，ctx被取消时停止清理，返回已经删除的个数和ctx.Err()，
+// 剩下的节点仍然是软删除状态，之后可以再次调用
+func (m *Map) CompactCtx(ctx context.Context) (int, error) {
+	if m.tombstones == 0 {
+		return 0, nil
+	}
+	// 删除节点时会移动键值对，所以先记录下所有要删除的key
+	var keys []keyItem
+	for node := m.root.minimum(); node != nil; node = node.next() {
+		if node.isDeleted() {
+			keys = append(keys, node.key)
+		}
+	}
+	removed := 0
+	for i, key := range keys {
+		if err := m.pause(ctx, i); err != nil {
+			return removed, err
+		}
+		if node, err := m.search(key); err == nil && !node.isLeaf() {
+			m.releaseVal(node.val)
+			m.eraseNode(node)
+			m.tombstones--
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// 恢复被软删除的节点
+func (m *Map) revive(node *Node) {
+	m.indexCounter(node.key, node.val)
+	node.setFlag(flagDeleted, false)
+	node.addCount(1)
+	m.lruTouch(node)
+	m.size++
+	m.tombstones--
+}
@@ -0,0 +1,20 @@
+package rbmap
+
+// SplitPoints 按照排名把当前的键值对平均分成n份（与DumpSharded的分法相同），返回后n-1份各自最小的key，
+// 第i份为[points[i-1], points[i])，用于分片存储或者并行导出前规划每个任务的key范围，
+// 每个分界点通过Select在O(log n)时间内找到，键值对个数少于n时只分成键值对个数份，没有键值对时返回nil
+func (m *Map) SplitPoints(n int) []keyItem {
+	if n > m.size {
+		n = m.size
+	}
+	if n <= 1 {
+		return nil
+	}
+	points := make([]keyItem, 0, n-1)
+	rank := 0
+	for i := 0; i < n-1; i++ {
+		rank += m.size/n + btoi(i < m.size%n)
+		points = append(points, m.selectNode(rank).key)
+	}
+	return points
+}
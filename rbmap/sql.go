@@ -0,0 +1,45 @@
+package rbmap
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"fmt"
+)
+
+// Value和Scan编码时使用的名字，开启加密时参与认证
+const sqlValueName = "sql value"
+
+// Value 实现driver.Valuer，把Map编码成与DumpSharded的分片文件相同格式的字节，可以直接存入BLOB列，
+// 压缩和加密配置同样生效，自定义的key和val类型需要先调用gob.Register注册
+func (m *Map) Value() (driver.Value, error) {
+	var buf bytes.Buffer
+	if err := m.writeChunks(context.Background(), &buf, sqlValueName, m.root.minimum().skipForward(), m.size); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Scan 实现sql.Scanner，用Value编码的字节替换Map的全部内容，Map需要先用NewMap创建以确定比较方法，
+// src为nil（数据库中的NULL）时清空Map
+func (m *Map) Scan(src any) error {
+	var data []byte
+	switch src := src.(type) {
+	case nil:
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return fmt.Errorf("rbmap: cannot scan %T into Map", src)
+	}
+	pairs, err := m.readChunks(bytes.NewReader(data), sqlValueName)
+	if err != nil {
+		return err
+	}
+	// 在新的树中构建后再替换，原来的访问顺序、值去重等状态由ReplaceAll处理
+	staging := NewMap(m.compareFunc)
+	staging.loadPairs(pairs)
+	m.ReplaceAll(staging)
+	return nil
+}
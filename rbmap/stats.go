@@ -0,0 +1,61 @@
+package rbmap
+
+// 树的统计和诊断信息
+
+// KeyBucket 一段连续key的统计信息，Lo和Hi为这一段中最小和最大的key
+type KeyBucket struct {
+	Lo, Hi interface{}
+	Count  int
+}
+
+// KeyDistribution 按照排名把所有key平均分成buckets段，返回每一段的边界和个数，
+// 用来观察key在键空间中的分布，帮助选择分片的切分点，段数多于key的个数时只返回非空的段，
+// 每段的边界通过Select查找，时间复杂度O(buckets·log n)
+func (m *Map) KeyDistribution(buckets int) []KeyBucket {
+	if buckets < 1 || m.size == 0 {
+		return nil
+	}
+	if buckets > m.size {
+		buckets = m.size
+	}
+	result := make([]KeyBucket, 0, buckets)
+	for i, rank := 0, 0; i < buckets; i++ {
+		count := m.size/buckets + btoi(i < m.size%buckets)
+		result = append(result, KeyBucket{
+			Lo:    m.selectNode(rank).key,
+			Hi:    m.selectNode(rank + count - 1).key,
+			Count: count,
+		})
+		rank += count
+	}
+	return result
+}
+
+// DepthHistogram 返回每一层的节点个数，下标为深度，根节点的深度为0，
+// 可以用来衡量树的形状与最优形状的差距，比如比较批量构建和逐个插入的效果，被软删除的节点也会被统计；
+// 树的深度超过限制时返回ErrTreeCorrupted
+func (m *Map) DepthHistogram() ([]int, error) {
+	var hist []int
+	limit := m.depthLimit()
+	var walk func(node *Node, depth int) error
+	walk = func(node *Node, depth int) error {
+		if node.isLeaf() {
+			return nil
+		}
+		if depth == limit {
+			return ErrTreeCorrupted
+		}
+		if depth == len(hist) {
+			hist = append(hist, 0)
+		}
+		hist[depth]++
+		if err := walk(node.left, depth+1); err != nil {
+			return err
+		}
+		return walk(node.right, depth+1)
+	}
+	if err := walk(m.root, 0); err != nil {
+		return nil, err
+	}
+	return hist, nil
+}
@@ -0,0 +1,396 @@
+package rbmap
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+)
+
+// 泛型版本的红黑树：key和val是具体的类型，不需要类型断言，也没有装箱到interface{}的分配；
+// Map这个名字已经被非泛型版本使用，所以泛型版本叫做TreeMap，
+// 为了减少分配，叶子节点直接使用nil表示，不像Map那样为每个叶子创建节点
+
+// 泛型红黑树的节点
+type treeNode[K, V any] struct {
+	key                 K
+	val                 V
+	left, right, parent *treeNode[K, V]
+	color               bool
+}
+
+// TreeMap 泛型版本的Map，比较方法的返回值与cmp.Compare相同：a < b时为负数，a == b时为0，a > b时为正数
+type TreeMap[K, V any] struct {
+	root    *treeNode[K, V]
+	size    int
+	compare func(a, b K) int
+}
+
+// NewTreeMap 传入比较key值的函数作为构造方法
+func NewTreeMap[K, V any](compare func(a, b K) int) *TreeMap[K, V] {
+	return &TreeMap[K, V]{compare: compare}
+}
+
+// NewOrderedTreeMap 创建key为int、string、float等可以用 < 比较的类型的TreeMap，使用cmp.Compare比较
+func NewOrderedTreeMap[K Ordered, V any]() *TreeMap[K, V] {
+	return NewTreeMap[K, V](cmp.Compare[K])
+}
+
+// Len 获得键值对的个数
+func (t *TreeMap[K, V]) Len() int {
+	return t.size
+}
+
+// Add 添加键值对，如果key存在就设置val的值并且返回错误
+func (t *TreeMap[K, V]) Add(key K, val V) error {
+	var parent *treeNode[K, V]
+	c := 0
+	for node := t.root; node != nil; {
+		parent = node
+		c = t.compare(key, node.key)
+		if c < 0 {
+			node = node.left
+		} else if c > 0 {
+			node = node.right
+		} else {
+			node.val = val
+			return ErrNodeAlreadyExists
+		}
+	}
+	node := &treeNode[K, V]{key: key, val: val, parent: parent, color: RED}
+	if parent == nil {
+		t.root = node
+	} else if c < 0 {
+		parent.left = node
+	} else {
+		parent.right = node
+	}
+	t.size++
+	t.insertFixup(node)
+	return nil
+}
+
+// Delete 删除key，如果不存在返回错误
+func (t *TreeMap[K, V]) Delete(key K) error {
+	node := t.find(key)
+	if node == nil {
+		return ErrNodeNotExists
+	}
+	t.remove(node)
+	return nil
+}
+
+// Set 设置key的值为val, 如果key不存在就返回false
+func (t *TreeMap[K, V]) Set(key K, val V) bool {
+	node := t.find(key)
+	if node == nil {
+		return false
+	}
+	node.val = val
+	return true
+}
+
+// Get 获得key对应的val,如果没有返回false和V的零值
+func (t *TreeMap[K, V]) Get(key K) (bool, V) {
+	node := t.find(key)
+	if node == nil {
+		var zero V
+		return false, zero
+	}
+	return true, node.val
+}
+
+// ForEach 按照从小到大的顺序依次对键值对调用fn，fn返回false时停止遍历，fn中不能修改树
+func (t *TreeMap[K, V]) ForEach(fn func(key K, val V) bool) {
+	for node := t.root.minimum(); node != nil && fn(node.key, node.val); node = node.next() {
+	}
+}
+
+// All 按照从小到大的顺序返回所有键值对的迭代器，可以用于 for key, val := range t.All()，
+// 不需要goroutine和通道，提前break也不会泄漏，遍历期间不能修改树
+func (t *TreeMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.ForEach(yield)
+	}
+}
+
+// Range 按照从小到大的顺序返回键值对，和Map.Range一样需要把通道读完，新代码建议使用All
+func (t *TreeMap[K, V]) Range() <-chan GenericPair[K, V] {
+	ch := make(chan GenericPair[K, V])
+	go func() {
+		t.ForEach(func(key K, val V) bool {
+			ch <- GenericPair[K, V]{Key: key, Val: val}
+			return true
+		})
+		close(ch)
+	}()
+	return ch
+}
+
+// Validate 检查整棵树是否满足红黑树的性质、父子指针是否一致以及key是否有序，不满足时返回ErrTreeCorrupted
+func (t *TreeMap[K, V]) Validate() error {
+	if t.root != nil && (t.root.parent != nil || t.root.color == RED) {
+		return fmt.Errorf("%w: invalid root", ErrTreeCorrupted)
+	}
+	count := 0
+	var check func(node *treeNode[K, V]) (int, error)
+	// 返回子树的黑高
+	check = func(node *treeNode[K, V]) (int, error) {
+		if node == nil {
+			return 1, nil
+		}
+		count++
+		for _, child := range []*treeNode[K, V]{node.left, node.right} {
+			if child == nil {
+				continue
+			}
+			if child.parent != node {
+				return 0, fmt.Errorf("%w: broken parent pointer at key %v", ErrTreeCorrupted, node.key)
+			}
+			if node.color == RED && child.color == RED {
+				return 0, fmt.Errorf("%w: red node %v has red child", ErrTreeCorrupted, node.key)
+			}
+		}
+		if node.left != nil && t.compare(node.left.key, node.key) >= 0 ||
+			node.right != nil && t.compare(node.key, node.right.key) >= 0 {
+			return 0, fmt.Errorf("%w: keys out of order at key %v", ErrTreeCorrupted, node.key)
+		}
+		l, err := check(node.left)
+		if err != nil {
+			return 0, err
+		}
+		r, err := check(node.right)
+		if err != nil {
+			return 0, err
+		}
+		if l != r {
+			return 0, fmt.Errorf("%w: unequal black height at key %v", ErrTreeCorrupted, node.key)
+		}
+		return l + btoi(node.color == BLACK), nil
+	}
+	if _, err := check(t.root); err != nil {
+		return err
+	}
+	if count != t.size {
+		return fmt.Errorf("%w: size %d but %d nodes", ErrTreeCorrupted, t.size, count)
+	}
+	return nil
+}
+
+// private:
+
+// 寻找key对应的节点，不存在时返回nil
+func (t *TreeMap[K, V]) find(key K) *treeNode[K, V] {
+	node := t.root
+	for node != nil {
+		c := t.compare(key, node.key)
+		if c < 0 {
+			node = node.left
+		} else if c > 0 {
+			node = node.right
+		} else {
+			return node
+		}
+	}
+	return node
+}
+
+// nil节点视为黑色
+func (n *treeNode[K, V]) isRed() bool {
+	return n != nil && n.color == RED
+}
+
+// 获得子树中最小的节点，子树为空时返回nil
+func (n *treeNode[K, V]) minimum() *treeNode[K, V] {
+	if n == nil {
+		return nil
+	}
+	for n.left != nil {
+		n = n.left
+	}
+	return n
+}
+
+// 获得子树中最大的节点，子树为空时返回nil
+func (n *treeNode[K, V]) maximum() *treeNode[K, V] {
+	if n == nil {
+		return nil
+	}
+	for n.right != nil {
+		n = n.right
+	}
+	return n
+}
+
+// 获得中序遍历的后继节点，没有时返回nil
+func (n *treeNode[K, V]) next() *treeNode[K, V] {
+	if n.right != nil {
+		return n.right.minimum()
+	}
+	for n.parent != nil && n == n.parent.right {
+		n = n.parent
+	}
+	return n.parent
+}
+
+// 插入红色节点后的调整
+func (t *TreeMap[K, V]) insertFixup(node *treeNode[K, V]) {
+	for node.parent.isRed() {
+		// 父节点是红色，所以祖父节点一定存在
+		parent, grandParent := node.parent, node.parent.parent
+		if parent == grandParent.left {
+			if uncle := grandParent.right; uncle.isRed() {
+				// 叔父节点是红色，父节点和叔父节点变黑，祖父节点变红后继续调整祖父节点
+				parent.color, uncle.color, grandParent.color = BLACK, BLACK, RED
+				node = grandParent
+				continue
+			}
+			if node == parent.right {
+				// 左右，先左旋父节点变成左左
+				node = parent
+				t.rotateLeft(node)
+				parent = node.parent
+			}
+			// 左左，交换父节点与祖父节点颜色后右旋祖父节点
+			parent.color, grandParent.color = BLACK, RED
+			t.rotateRight(grandParent)
+		} else {
+			if uncle := grandParent.left; uncle.isRed() {
+				parent.color, uncle.color, grandParent.color = BLACK, BLACK, RED
+				node = grandParent
+				continue
+			}
+			if node == parent.left {
+				// 右左，先右旋父节点变成右右
+				node = parent
+				t.rotateRight(node)
+				parent = node.parent
+			}
+			// 右右，交换父节点与祖父节点颜色后左旋祖父节点
+			parent.color, grandParent.color = BLACK, RED
+			t.rotateLeft(grandParent)
+		}
+	}
+	t.root.color = BLACK
+}
+
+// 删除节点，有两个儿子时用后继节点替换它的位置，这样节点本身不会移动键值对
+func (t *TreeMap[K, V]) remove(node *treeNode[K, V]) {
+	// child为替换到被删除位置的子树，parent为它的父节点，child可能为nil
+	var child, parent *treeNode[K, V]
+	removedColor := node.color
+	if node.left == nil {
+		child, parent = node.right, node.parent
+		t.transplant(node, node.right)
+	} else if node.right == nil {
+		child, parent = node.left, node.parent
+		t.transplant(node, node.left)
+	} else {
+		successor := node.right.minimum()
+		removedColor = successor.color
+		child, parent = successor.right, successor
+		if successor.parent != node {
+			parent = successor.parent
+			t.transplant(successor, successor.right)
+			successor.right = node.right
+			successor.right.parent = successor
+		}
+		t.transplant(node, successor)
+		successor.left = node.left
+		successor.left.parent = successor
+		successor.color = node.color
+	}
+	t.size--
+	if removedColor == BLACK {
+		t.removeFixup(child, parent)
+	}
+}
+
+// 删除黑色节点后的调整，node所在的子树比兄弟子树少一个黑色节点
+func (t *TreeMap[K, V]) removeFixup(node, parent *treeNode[K, V]) {
+	for node != t.root && !node.isRed() {
+		if node == parent.left {
+			sibling := parent.right
+			if sibling.isRed() {
+				// 兄弟节点是红色，旋转后让兄弟节点变成黑色
+				sibling.color, parent.color = BLACK, RED
+				t.rotateLeft(parent)
+				sibling = parent.right
+			}
+			if !sibling.left.isRed() && !sibling.right.isRed() {
+				// 兄弟节点的两个儿子都是黑色，兄弟节点变红后继续调整父节点
+				sibling.color = RED
+				node, parent = parent, parent.parent
+				continue
+			}
+			if !sibling.right.isRed() {
+				// 兄弟节点的左儿子是红色，先右旋兄弟节点
+				sibling.left.color, sibling.color = BLACK, RED
+				t.rotateRight(sibling)
+				sibling = parent.right
+			}
+			sibling.color, parent.color, sibling.right.color = parent.color, BLACK, BLACK
+			t.rotateLeft(parent)
+		} else {
+			sibling := parent.left
+			if sibling.isRed() {
+				sibling.color, parent.color = BLACK, RED
+				t.rotateRight(parent)
+				sibling = parent.left
+			}
+			if !sibling.left.isRed() && !sibling.right.isRed() {
+				sibling.color = RED
+				node, parent = parent, parent.parent
+				continue
+			}
+			if !sibling.left.isRed() {
+				sibling.right.color, sibling.color = BLACK, RED
+				t.rotateLeft(sibling)
+				sibling = parent.left
+			}
+			sibling.color, parent.color, sibling.left.color = parent.color, BLACK, BLACK
+			t.rotateRight(parent)
+		}
+		node = t.root
+	}
+	if node != nil {
+		node.color = BLACK
+	}
+}
+
+// 用以replacement为根的子树替换以node为根的子树
+func (t *TreeMap[K, V]) transplant(node, replacement *treeNode[K, V]) {
+	if node.parent == nil {
+		t.root = replacement
+	} else if node == node.parent.left {
+		node.parent.left = replacement
+	} else {
+		node.parent.right = replacement
+	}
+	if replacement != nil {
+		replacement.parent = node.parent
+	}
+}
+
+// 左旋
+func (t *TreeMap[K, V]) rotateLeft(node *treeNode[K, V]) {
+	right := node.right
+	node.right = right.left
+	if right.left != nil {
+		right.left.parent = node
+	}
+	t.transplant(node, right)
+	right.left = node
+	node.parent = right
+}
+
+// 右旋
+func (t *TreeMap[K, V]) rotateRight(node *treeNode[K, V]) {
+	left := node.left
+	node.left = left.right
+	if left.right != nil {
+		left.right.parent = node
+	}
+	t.transplant(node, left)
+	left.right = node
+	node.parent = left
+}
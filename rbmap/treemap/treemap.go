@@ -0,0 +1,207 @@
+// Package treemap: 在rbmap之上实现emirpasic/gods的treemap风格接口，从gods迁移或者与gods做对比测试时不需要修改调用的代码
+package treemap
+
+import (
+	"fmt"
+	"rbtree/rbmap"
+	"strings"
+)
+
+// Comparator 与gods的utils.Comparator相同，a < b时返回负数，a == b时返回0，a > b时返回正数
+type Comparator func(a, b interface{}) int
+
+// Map 使用rbmap.Map实现的gods风格的TreeMap
+type Map struct {
+	m          *rbmap.Map
+	comparator Comparator
+}
+
+// NewWith 使用comparator创建空的Map
+func NewWith(comparator Comparator) *Map {
+	return &Map{m: rbmap.NewMap(toCompareFunc(comparator)), comparator: comparator}
+}
+
+// NewWithIntComparator 创建key为int的Map
+func NewWithIntComparator() *Map {
+	return NewWith(func(a, b interface{}) int { return a.(int) - b.(int) })
+}
+
+// NewWithStringComparator 创建key为string的Map
+func NewWithStringComparator() *Map {
+	return NewWith(func(a, b interface{}) int { return strings.Compare(a.(string), b.(string)) })
+}
+
+// 把gods的比较方法转换为rbmap.CompareFunc
+func toCompareFunc(comparator Comparator) rbmap.CompareFunc {
+	return func(a, b interface{}) uint8 {
+		switch c := comparator(a, b); {
+		case c < 0:
+			return 1
+		case c > 0:
+			return 2
+		default:
+			return 0
+		}
+	}
+}
+
+// Put 添加或者覆盖key的值
+func (t *Map) Put(key, value interface{}) {
+	t.m.Add(key, value)
+}
+
+// Get 获得key的值，不存在时found为false
+func (t *Map) Get(key interface{}) (value interface{}, found bool) {
+	found, value = t.m.Get(key)
+	return value, found
+}
+
+// Remove 删除key，不存在时什么都不做
+func (t *Map) Remove(key interface{}) {
+	t.m.Delete(key)
+}
+
+// Empty 判断是否为空
+func (t *Map) Empty() bool {
+	return t.m.Len() == 0
+}
+
+// Size 获得键值对的个数
+func (t *Map) Size() int {
+	return t.m.Len()
+}
+
+// Keys 按照从小到大的顺序返回所有key
+func (t *Map) Keys() []interface{} {
+	keys := make([]interface{}, 0, t.m.Len())
+	for key := range t.m.Keys() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Values 按照key从小到大的顺序返回所有val
+func (t *Map) Values() []interface{} {
+	values := make([]interface{}, 0, t.m.Len())
+	for val := range t.m.Values() {
+		values = append(values, val)
+	}
+	return values
+}
+
+// Clear 删除所有键值对
+func (t *Map) Clear() {
+	t.m.ReplaceAll(rbmap.NewMap(nil))
+}
+
+// Min 获得最小的键值对，为空时返回nil, nil
+func (t *Map) Min() (key, value interface{}) {
+	t.m.ForEach(func(k, v interface{}) bool {
+		key, value = k, v
+		return false
+	})
+	return key, value
+}
+
+// Max 获得最大的键值对，为空时返回nil, nil
+func (t *Map) Max() (key, value interface{}) {
+	t.m.Descending().ForEach(func(k, v interface{}) bool {
+		key, value = k, v
+		return false
+	})
+	return key, value
+}
+
+// Floor 获得小于等于key的最大的键值对，没有时返回nil, nil
+func (t *Map) Floor(key interface{}) (foundKey, foundValue interface{}) {
+	t.m.Descending().ForEachFrom(key, func(k, v interface{}) bool {
+		foundKey, foundValue = k, v
+		return false
+	})
+	return foundKey, foundValue
+}
+
+// Ceiling 获得大于等于key的最小的键值对，没有时返回nil, nil
+func (t *Map) Ceiling(key interface{}) (foundKey, foundValue interface{}) {
+	t.m.ForEachFrom(key, func(k, v interface{}) bool {
+		foundKey, foundValue = k, v
+		return false
+	})
+	return foundKey, foundValue
+}
+
+// String 与gods相同格式的字符串表示
+func (t *Map) String() string {
+	var sb strings.Builder
+	sb.WriteString("TreeMap\nmap[")
+	first := true
+	t.m.ForEach(func(key, val interface{}) bool {
+		if !first {
+			sb.WriteByte(' ')
+		}
+		first = false
+		fmt.Fprintf(&sb, "%v:%v", key, val)
+		return true
+	})
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// Iterator 与gods相同的双向迭代器，创建时复制一份所有的键值对，之后对Map的修改不会影响迭代器
+type Iterator struct {
+	pairs []rbmap.Pair
+	index int
+}
+
+// Iterator 获得指向第一个键值对之前的迭代器，需要O(n)的时间复制键值对
+func (t *Map) Iterator() *Iterator {
+	return &Iterator{pairs: t.m.Pairs(), index: -1}
+}
+
+// Next 移动到下一个键值对，已经没有时返回false
+func (it *Iterator) Next() bool {
+	if it.index < len(it.pairs) {
+		it.index++
+	}
+	return it.index < len(it.pairs)
+}
+
+// Prev 移动到上一个键值对，已经没有时返回false
+func (it *Iterator) Prev() bool {
+	if it.index >= 0 {
+		it.index--
+	}
+	return it.index >= 0
+}
+
+// Key 获得当前的key
+func (it *Iterator) Key() interface{} {
+	return it.pairs[it.index].Key
+}
+
+// Value 获得当前的val
+func (it *Iterator) Value() interface{} {
+	return it.pairs[it.index].Val
+}
+
+// Begin 回到第一个键值对之前
+func (it *Iterator) Begin() {
+	it.index = -1
+}
+
+// End 移动到最后一个键值对之后
+func (it *Iterator) End() {
+	it.index = len(it.pairs)
+}
+
+// First 移动到第一个键值对，为空时返回false
+func (it *Iterator) First() bool {
+	it.Begin()
+	return it.Next()
+}
+
+// Last 移动到最后一个键值对，为空时返回false
+func (it *Iterator) Last() bool {
+	it.End()
+	return it.Prev()
+}
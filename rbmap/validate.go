@@ -0,0 +1,167 @@
+package rbmap
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Validate 检查整棵树是否满足红黑树的性质、父子指针是否一致以及key是否有序，不满足时返回ErrTreeCorrupted
+func (m *Map) Validate() error {
+	if !m.root.isRoot() || m.root.isRed() {
+		return fmt.Errorf("%w: invalid root", ErrTreeCorrupted)
+	}
+	count := 0
+	var check func(node *Node, depth int) (int, error)
+	// 返回子树的黑高，最多向下depth层，环形的指针不会导致无限递归
+	check = func(node *Node, depth int) (int, error) {
+		if node.isLeaf() {
+			return 1, nil
+		}
+		if depth == 0 {
+			return 0, fmt.Errorf("%w: depth limit exceeded at key %v", ErrTreeCorrupted, node.key)
+		}
+		if err := m.checkNode(node); err != nil {
+			return 0, err
+		}
+		count += btoi(!node.isDeleted())
+		l, err := check(node.left, depth-1)
+		if err != nil {
+			return 0, err
+		}
+		r, err := check(node.right, depth-1)
+		if err != nil {
+			return 0, err
+		}
+		if l != r {
+			return 0, fmt.Errorf("%w: unequal black height at key %v", ErrTreeCorrupted, node.key)
+		}
+		if node.count != node.left.count+node.right.count+btoi(!node.isDeleted()) {
+			return 0, fmt.Errorf("%w: wrong subtree size at key %v", ErrTreeCorrupted, node.key)
+		}
+		return l + btoi(node.isBlack()), nil
+	}
+	if _, err := check(m.root, m.depthLimit()); err != nil {
+		return err
+	}
+	if count != m.size {
+		return fmt.Errorf("%w: size %d but %d nodes", ErrTreeCorrupted, m.size, count)
+	}
+	return nil
+}
+
+// 检查节点的局部性质
+func (m *Map) checkNode(node *Node) error {
+	if !node.left.isLeaf() && node.left.parent != node || !node.right.isLeaf() && node.right.parent != node {
+		return fmt.Errorf("%w: broken parent pointer at key %v", ErrTreeCorrupted, node.key)
+	}
+	if node.isRoot() && m.root != node {
+		return fmt.Errorf("%w: detached node at key %v", ErrTreeCorrupted, node.key)
+	}
+	if node.isRed() && (node.left.isRed() || node.right.isRed()) {
+		return fmt.Errorf("%w: red node %v has red child", ErrTreeCorrupted, node.key)
+	}
+	if !node.left.isLeaf() && m.compareFunc(node.left.key, node.key) != 1 ||
+		!node.right.isLeaf() && m.compareFunc(node.key, node.right.key) != 1 {
+		return fmt.Errorf("%w: keys out of order at key %v", ErrTreeCorrupted, node.key)
+	}
+	return nil
+}
+
+// BackgroundValidation 后台增量校验的句柄
+type BackgroundValidation struct {
+	stop chan struct{}
+	done chan struct{}
+	mu   sync.Mutex
+	err  error
+}
+
+// StartBackgroundValidation 开启一个后台协程，每隔interval按照key的顺序检查最多batch个节点的局部性质，
+// 把完整校验的开销分散到一段时间里，适合无法承受一次完整Validate停顿的服务
+//
+// 每次检查时都会持有locker，locker必须是保护这个Map写操作的锁；发现错误后后台协程停止，错误可以通过Err获得；
+// interval不大于0时按照1ms处理，batch小于1时按照1处理
+func (m *Map) StartBackgroundValidation(interval time.Duration, batch int, locker sync.Locker) *BackgroundValidation {
+	if interval <= 0 {
+		interval = time.Millisecond
+	}
+	if batch < 1 {
+		batch = 1
+	}
+	v := &BackgroundValidation{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	m.track(v)
+	go func() {
+		defer close(v.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var last keyItem
+		started := false
+		for {
+			select {
+			case <-v.stop:
+				return
+			case <-ticker.C:
+			}
+			locker.Lock()
+			var err error
+			last, started, err = m.validateBatch(last, started, batch)
+			locker.Unlock()
+			if err != nil {
+				v.mu.Lock()
+				v.err = err
+				v.mu.Unlock()
+				return
+			}
+		}
+	}()
+	return v
+}
+
+// Stop 停止后台校验并等待后台协程退出
+func (v *BackgroundValidation) Stop() {
+	v.signal()
+	<-v.done
+}
+
+// 通知后台协程退出，不等待
+func (v *BackgroundValidation) signal() {
+	select {
+	case <-v.stop:
+	default:
+		close(v.stop)
+	}
+}
+
+// Err 获得后台校验发现的错误，没有错误时返回nil
+func (v *BackgroundValidation) Err() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.err
+}
+
+// 从上次检查到的key之后继续检查batch个节点，到达末尾后从头开始，返回这次检查到的最后一个key
+func (m *Map) validateBatch(last keyItem, started bool, batch int) (keyItem, bool, error) {
+	if !m.root.isRoot() || m.root.isRed() {
+		return last, started, fmt.Errorf("%w: invalid root", ErrTreeCorrupted)
+	}
+	node := m.root.minimum()
+	if started {
+		if node = m.ceilingNode(last); node != nil && m.compareFunc(node.key, last) == 0 {
+			node = node.next()
+		}
+	}
+	for ; batch > 0; batch-- {
+		if node == nil {
+			return nil, false, nil
+		}
+		if err := m.checkNode(node); err != nil {
+			return last, started, err
+		}
+		last, started = node.key, true
+		node = node.next()
+	}
+	return last, started, nil
+}
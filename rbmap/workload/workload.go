@@ -0,0 +1,135 @@
+// Package workload: 可复现的操作序列生成器，生成的oracle.Op可以交给oracle.CrossCheck或者性能测试使用
+package workload
+
+import (
+	"math/rand"
+	"rbtree/rbmap/oracle"
+	"time"
+)
+
+// KeyDist key的分布
+type KeyDist uint8
+
+const (
+	// Uniform 	在[0, Keys)中均匀分布
+	Uniform KeyDist = iota
+	// Zipfian 	在[0, Keys)中服从Zipf分布，小的key出现得更多
+	Zipfian
+	// Sequential 	写操作的key依次递增
+	Sequential
+	// TimeBased 	写操作的key是以Start为起点、间隔约为Step的纳秒时间戳，带有随机抖动
+	TimeBased
+)
+
+// Config 生成器的配置，生成的key都是int类型
+type Config struct {
+	Seed        int64         // 随机种子，相同的配置和种子生成相同的操作序列
+	Dist        KeyDist       // key的分布
+	Keys        int           // Uniform和Zipfian的key空间大小
+	ZipfS       float64       // Zipfian的参数s，必须大于1，默认为1.1
+	ReadRatio   float64       // Get操作的比例
+	DeleteRatio float64       // Delete操作的比例，其余的都是Add操作
+	Start       time.Time     // TimeBased的起始时间，默认为Unix纪元
+	Step        time.Duration // TimeBased相邻两个key的平均间隔，默认为1ms
+}
+
+// Generator 操作序列生成器
+type Generator struct {
+	cfg  Config
+	rand *rand.Rand
+	zipf *rand.Zipf
+	// 已经生成的写操作个数，Sequential和TimeBased使用
+	writes int
+	// TimeBased的上一个key
+	last int
+	// TimeBased已经写过的key，读和删除操作从中选择
+	written []int
+}
+
+// New 根据配置创建生成器
+func New(cfg Config) *Generator {
+	if cfg.Keys < 1 {
+		cfg.Keys = 1
+	}
+	if cfg.ZipfS <= 1 {
+		cfg.ZipfS = 1.1
+	}
+	if cfg.Step <= 0 {
+		cfg.Step = time.Millisecond
+	}
+	if cfg.Start.IsZero() {
+		// 零值时间的UnixNano超出int64的范围，结果没有定义
+		cfg.Start = time.Unix(0, 0)
+	}
+	g := &Generator{
+		cfg:  cfg,
+		rand: rand.New(rand.NewSource(cfg.Seed)),
+		last: int(cfg.Start.UnixNano()),
+	}
+	if cfg.Dist == Zipfian {
+		g.zipf = rand.NewZipf(g.rand, cfg.ZipfS, 1, uint64(cfg.Keys-1))
+	}
+	return g
+}
+
+// Next 生成下一个操作
+func (g *Generator) Next() oracle.Op {
+	p := g.rand.Float64()
+	if p < g.cfg.ReadRatio {
+		return oracle.Op{Kind: oracle.OpGet, Key: g.existingKey()}
+	} else if p < g.cfg.ReadRatio+g.cfg.DeleteRatio {
+		return oracle.Op{Kind: oracle.OpDelete, Key: g.existingKey()}
+	}
+	return oracle.Op{Kind: oracle.OpAdd, Key: g.writeKey(), Val: g.rand.Int()}
+}
+
+// Ops 生成n个操作
+func (g *Generator) Ops(n int) []oracle.Op {
+	ops := make([]oracle.Op, n)
+	for i := range ops {
+		ops[i] = g.Next()
+	}
+	return ops
+}
+
+// 生成写操作的key
+func (g *Generator) writeKey() int {
+	switch g.cfg.Dist {
+	case Sequential:
+		g.writes++
+		return g.writes - 1
+	case TimeBased:
+		g.writes++
+		// 间隔在[Step/2, Step*3/2)之间抖动
+		step := int(g.cfg.Step)
+		g.last += step/2 + g.rand.Intn(step)
+		g.written = append(g.written, g.last)
+		return g.last
+	}
+	return g.randomKey()
+}
+
+// 生成读操作和删除操作的key，Sequential和TimeBased从已经写过的key里选，还没有写过时返回一个不存在的key
+func (g *Generator) existingKey() int {
+	switch g.cfg.Dist {
+	case Sequential:
+		if g.writes == 0 {
+			return 0
+		}
+		return g.rand.Intn(g.writes)
+	case TimeBased:
+		if len(g.written) == 0 {
+			return int(g.cfg.Start.UnixNano())
+		}
+		return g.written[g.rand.Intn(len(g.written))]
+	}
+	return g.randomKey()
+}
+
+// 在key空间中按照分布随机生成一个key
+func (g *Generator) randomKey() int {
+	if g.zipf != nil {
+		return int(g.zipf.Uint64())
+	}
+	return g.rand.Intn(g.cfg.Keys)
+}
@@ -0,0 +1,24 @@
+package rbmap
+
+import (
+	"context"
+	"runtime"
+)
+
+// 长时间运行的操作（DumpShardedCtx、CompactCtx、DeleteRangeCtx等）在遍历节点时定期检查ctx并让出处理器，
+// 后台的维护任务可以被取消，也不会长时间占住处理器影响前台请求
+
+// WithYieldEvery 长时间运行的操作每处理n个节点调用一次runtime.Gosched，n小于等于0时不主动让出
+func WithYieldEvery(n int) Option {
+	return func(m *Map) {
+		m.yieldEvery = n
+	}
+}
+
+// 长时间运行的操作每处理一个节点调用一次，done为已经处理的节点个数，ctx被取消时返回ctx.Err()
+func (m *Map) pause(ctx context.Context, done int) error {
+	if m.yieldEvery > 0 && done > 0 && done%m.yieldEvery == 0 {
+		runtime.Gosched()
+	}
+	return ctx.Err()
+}